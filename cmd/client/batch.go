@@ -0,0 +1,356 @@
+package main
+
+import (
+	"os"
+	"io"
+	"fmt"
+	"errors"
+	"context"
+	"strconv"
+	"strings"
+	"math/big"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+// Row of batch file. Type is estate, present or sale. EstateId of present
+// or sale can be #<row> to refer to estate created by that row of batch.
+type batchRow struct {
+	Type string `json:"type"`
+	Owner string `json:"owner"`
+	Info string `json:"info"`
+	Squere string `json:"squere"`
+	UsefulSquere string `json:"useful_squere"`
+	EstateId string `json:"estate_id"`
+	Address string `json:"address"`
+	Price string `json:"price"`
+}
+
+// Line of batch report. Status is invalid, refused, failed, skipped,
+// pending, reverted or success.
+type batchResult struct {
+	Row int `json:"row"`
+	Type string `json:"type"`
+	EstateId string `json:"estate_id"`
+	TxHash string `json:"tx_hash"`
+	Status string `json:"status"`
+	Error string `json:"error"`
+}
+
+// Arguments of batch row checked before sending.
+type batchItem struct {
+	row *batchRow
+	result *batchResult
+	owner common.Address
+	squere *big.Int
+	usefulSquere *big.Int
+	estateId *big.Int
+	estateRef int
+	address common.Address
+	price *big.Int
+	tx *types.Transaction
+}
+
+var batchHeader = []string{"row", "type", "estate_id", "tx_hash", "status", "error"}
+
+// chain batch file report
+// Create estates of file first, then presents and sales, so that they
+// can refer to new estates. Nonces are handed out by Nonces one by one.
+func chainBatch(args []string) (interface{}, error) {
+	rows, err := readBatch(args[0])
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_USAGE, err)
+	}
+	var (
+		items = make([]*batchItem, len(rows))
+		results = make([]*batchResult, len(rows))
+		invalid = 0
+	)
+	for i, row := range rows {
+		items[i] = &batchItem{
+			row: row,
+			result: &batchResult{Row: i + 1, Type: row.Type},
+		}
+		results[i] = items[i].result
+		if err := items[i].validate(len(rows)); err != nil {
+			items[i].result.Status = "invalid"
+			items[i].result.Error = err.Error()
+			invalid++
+		}
+	}
+	for _, item := range items {
+		if item.estateRef != 0 && items[item.estateRef-1].row.Type != "estate" {
+			item.result.Status = "invalid"
+			item.result.Error = fmt.Sprintf("row %d does not create estate", item.estateRef)
+			invalid++
+		}
+	}
+	if invalid != 0 {
+		if err := writeBatchReport(args[1], results); err != nil {
+			return nil, err
+		}
+		return results, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("%d of %d rows are invalid, nothing is sent", invalid, len(rows)))
+	}
+
+	auth, err := worldskills.ResetAuth(User)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	for _, estates := range []bool{true, false} {
+		var sent []*batchItem
+		for _, item := range items {
+			if (item.row.Type == "estate") != estates {
+				continue
+			}
+			if item.estateRef != 0 {
+				ref := items[item.estateRef-1].result
+				if ref.Status != "success" || ref.EstateId == "" {
+					item.result.Status = "skipped"
+					item.result.Error = fmt.Sprintf("estate of row %d is not created", item.estateRef)
+					continue
+				}
+				item.estateId, _ = new(big.Int).SetString(ref.EstateId, 10)
+				item.result.EstateId = ref.EstateId
+			}
+			opts := *auth
+			if err := item.send(&opts); err != nil {
+				item.result.Error = err.Error()
+				continue
+			}
+			sent = append(sent, item)
+		}
+		for _, item := range sent {
+			item.wait()
+		}
+	}
+
+	if err := writeBatchReport(args[1], results); err != nil {
+		return results, err
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Status != "success" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return results, worldskills.WithExit(worldskills.EXIT_TX, fmt.Errorf("%d of %d rows failed, see %s", failed, len(rows), args[1]))
+	}
+	return results, nil
+}
+
+// Parse arguments of row, count is number of rows in batch.
+func (item *batchItem) validate(count int) error {
+	var (
+		row = item.row
+		err error
+	)
+	switch row.Type {
+	case "estate":
+		switch {
+		case row.Owner == "my":
+			item.owner = User.AddressEth
+		case common.IsHexAddress(row.Owner):
+			item.owner = common.HexToAddress(row.Owner)
+		default:
+			return fmt.Errorf("owner %q is not my or address", row.Owner)
+		}
+		if item.squere, err = batchNumber("squere", row.Squere); err != nil {
+			return err
+		}
+		if item.usefulSquere, err = batchNumber("useful_squere", row.UsefulSquere); err != nil {
+			return err
+		}
+	case "present", "sale":
+		if strings.HasPrefix(row.EstateId, "#") {
+			item.estateRef, err = strconv.Atoi(strings.TrimPrefix(row.EstateId, "#"))
+			if err != nil || item.estateRef < 1 || item.estateRef > count {
+				return fmt.Errorf("estate_id %q does not refer to row of batch", row.EstateId)
+			}
+		} else if item.estateId, err = batchNumber("estate_id", row.EstateId); err != nil {
+			return err
+		}
+		if row.Type == "present" {
+			if !common.IsHexAddress(row.Address) {
+				return fmt.Errorf("address %q is invalid", row.Address)
+			}
+			item.address = common.HexToAddress(row.Address)
+		} else if item.price, err = batchNumber("price", row.Price); err != nil {
+			return err
+		}
+		item.result.EstateId = row.EstateId
+	default:
+		return fmt.Errorf("type %q is not estate, present or sale", row.Type)
+	}
+	return nil
+}
+
+func batchNumber(name string, value string) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(value, 10)
+	if !ok || num.Sign() < 0 {
+		return nil, fmt.Errorf("%s %q is not a number", name, value)
+	}
+	return num, nil
+}
+
+// Check row with Preflight and send it.
+func (item *batchItem) send(auth *bind.TransactOpts) error {
+	var (
+		tx *types.Transaction
+		err error
+	)
+	switch item.row.Type {
+	case "estate":
+		err = worldskills.Preflight(User, auth, "create_estate", item.owner, item.row.Info, item.squere, item.usefulSquere)
+	case "present":
+		err = worldskills.Preflight(User, auth, "create_present", item.estateId, item.address)
+	case "sale":
+		err = worldskills.Preflight(User, auth, "create_sale", item.estateId, item.price)
+	}
+	if err != nil {
+		item.result.Status = "failed"
+		var refused *worldskills.RefusedError
+		if errors.As(err, &refused) {
+			item.result.Status = "refused"
+		}
+		return err
+	}
+	tx, err = worldskills.SendTx(auth, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		switch item.row.Type {
+		case "estate":
+			return worldskills.Backend.CreateEstate(auth, item.owner, item.row.Info, item.squere, item.usefulSquere)
+		case "present":
+			return worldskills.Backend.CreatePresent(auth, item.estateId, item.address)
+		}
+		return worldskills.Backend.CreateSale(auth, item.estateId, item.price)
+	})
+	if err != nil {
+		item.result.Status = "failed"
+		return err
+	}
+	item.tx = tx
+	item.result.TxHash = tx.Hash().Hex()
+	return nil
+}
+
+// Wait receipt of sent row, id of new estate is taken from EstateCreated.
+func (item *batchItem) wait() {
+	receipt, err := worldskills.WaitTx(item.tx)
+	if err != nil {
+		item.result.Status = "pending"
+		item.result.Error = err.Error()
+		return
+	}
+	if !receipt.Success {
+		item.result.Status = "reverted"
+		return
+	}
+	item.result.Status = "success"
+	if item.row.Type != "estate" {
+		return
+	}
+	full, err := worldskills.ClientETH.TransactionReceipt(context.Background(), item.tx.Hash())
+	if err != nil {
+		item.result.Error = fmt.Sprintf("get receipt: %s", err)
+		return
+	}
+	for _, log := range full.Logs {
+		event, err := worldskills.ParseEvent(*log)
+		if err == nil && event.Name == "EstateCreated" {
+			item.result.EstateId = event.EstateId.String()
+		}
+	}
+}
+
+// Read rows from .json array or .csv with header of batchRow json names.
+func readBatch(path string) ([]*batchRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open batch: %w", err)
+	}
+	defer file.Close()
+	var rows []*batchRow
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&rows); err != nil {
+			return nil, fmt.Errorf("read batch %s: %w", path, err)
+		}
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("read batch %s: %w", path, err)
+		}
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("read batch %s: %w", path, err)
+			}
+			fields := make(map[string]string)
+			for i, name := range header {
+				if i < len(record) {
+					fields[strings.TrimSpace(name)] = strings.TrimSpace(record[i])
+				}
+			}
+			rows = append(rows, &batchRow{
+				Type: fields["type"],
+				Owner: fields["owner"],
+				Info: fields["info"],
+				Squere: fields["squere"],
+				UsefulSquere: fields["useful_squere"],
+				EstateId: fields["estate_id"],
+				Address: fields["address"],
+				Price: fields["price"],
+			})
+		}
+	default:
+		return nil, errors.New("batch file must be .csv or .json")
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("batch %s has no rows", path)
+	}
+	return rows, nil
+}
+
+// Write report as .json array or csv for any other extension.
+func writeBatchReport(path string, results []*batchResult) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	defer file.Close()
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "\t")
+		if err := encoder.Encode(results); err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		return nil
+	}
+	writer := csv.NewWriter(file)
+	writer.Write(batchHeader)
+	for _, result := range results {
+		writer.Write([]string{
+			strconv.Itoa(result.Row),
+			result.Type,
+			result.EstateId,
+			result.TxHash,
+			result.Status,
+			result.Error,
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,721 @@
+package main
+
+import (
+	"os"
+	"fmt"
+	"log"
+	"sort"
+	"io"
+	"bufio"
+	"errors"
+	"context"
+	"strings"
+	"strconv"
+	"math/big"
+	"os/signal"
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+var (
+	User *worldskills.UserType
+	// Format of results: text, table or json.
+	Output = "text"
+	// Command from command line, REPL is started if it is empty.
+	Command []string
+	Interactive bool
+	// Lines of REPL. One scanner reads stdin for the whole process,
+	// so no line is lost in buffer of another reader.
+	Input = bufio.NewScanner(os.Stdin)
+)
+
+// Command of client, Args is usage of its arguments.
+type commandType struct {
+	Args []string
+	Run func(args []string) (interface{}, error)
+}
+
+var commands = map[string]*commandType{
+	"user address": {nil, userAddress},
+	"user purse": {nil, userPurse},
+	"user balance": {nil, userBalance},
+	"chain get": {[]string{"estates|presents|sales|rents", "all|my|address"}, chainGet},
+	"chain events": {[]string{"from_block"}, chainEvents},
+	"chain history estate": {[]string{"id_estate"}, chainHistoryEstate},
+	"chain watch events": {nil, chainWatchEvents},
+	"chain create estate": {[]string{"my|address", "info", "squere", "usefulSquere"}, chainCreateEstate},
+	"chain create present": {[]string{"id_estate", "address"}, chainCreatePresent},
+	"chain create sale": {[]string{"id_estate", "price"}, chainCreateSale},
+	"chain create rent": {[]string{"id_estate", "days", "price"}, chainCreateRent},
+	"chain cancel present": {[]string{"id_present"}, chainCancelPresent},
+	"chain cancel sale": {[]string{"id_sale"}, chainCancelSale},
+	"chain cancel rent": {[]string{"id_rent"}, chainCancelRent},
+	"chain confirm present": {[]string{"id_present"}, chainConfirmPresent},
+	"chain confirm sale": {[]string{"id_sale", "id_customer"}, chainConfirmSale},
+	"chain bid sale": {[]string{"id_sale", "wei"}, chainBidSale},
+	"chain withdraw sale": {[]string{"id_sale"}, chainWithdrawSale},
+	"chain take rent": {[]string{"id_rent"}, chainTakeRent},
+	"chain finish rent": {[]string{"id_rent"}, chainFinishRent},
+	"chain batch": {[]string{"file.csv|file.json", "report"}, chainBatch},
+	"tx replace": {[]string{"tx_hash"}, txReplace},
+	"tx cancel": {[]string{"tx_hash"}, txCancel},
+}
+
+// Parse command line, connect to chain and load user.
+func setup() error {
+	if len(os.Args) < 2 {
+		return worldskills.WithExit(worldskills.EXIT_USAGE, errors.New("len(os.Args) < 2"))
+	}
+	var (
+		userLoadStr = ""
+		userLoadExist = false
+		keystorePath = ""
+		passfile = ""
+		newKeystoreDir = ""
+		importKeyDir = ""
+	)
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case strings.HasPrefix(arg, "-loaduser:"):
+			userLoadStr = strings.Replace(arg, "-loaduser:", "", 1)
+			userLoadExist = true
+		case strings.HasPrefix(arg, "-keystore:"):
+			keystorePath = strings.Replace(arg, "-keystore:", "", 1)
+			userLoadExist = true
+		case strings.HasPrefix(arg, "-passfile:"):
+			passfile = strings.Replace(arg, "-passfile:", "", 1)
+		case strings.HasPrefix(arg, "-newkeystore:"):
+			newKeystoreDir = strings.Replace(arg, "-newkeystore:", "", 1)
+		case strings.HasPrefix(arg, "-importkey:"):
+			importKeyDir = strings.Replace(arg, "-importkey:", "", 1)
+		case strings.HasPrefix(arg, "-output:"):
+			Output = strings.Replace(arg, "-output:", "", 1)
+		case strings.HasPrefix(arg, "--output="):
+			Output = strings.Replace(arg, "--output=", "", 1)
+		case arg == "--output":
+			if i+1 == len(os.Args) {
+				return worldskills.WithExit(worldskills.EXIT_USAGE, errors.New("--output needs json, table or text"))
+			}
+			i++
+			Output = os.Args[i]
+		case !strings.HasPrefix(arg, "-"):
+			Command = append(Command, arg)
+		}
+	}
+	switch Output {
+	case "text", "table", "json":
+	default:
+		return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("output %q is not json, table or text", Output))
+	}
+	if newKeystoreDir != "" {
+		if err := keystoreCreate(newKeystoreDir, passfile); err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, err)
+		}
+		os.Exit(0)
+	}
+	if importKeyDir != "" {
+		if err := keystoreImport(importKeyDir, passfile); err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, err)
+		}
+		os.Exit(0)
+	}
+	worldskills.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	if err := worldskills.SetupChain(os.Args[1:]); err != nil {
+		return err
+	}
+	if !userLoadExist && worldskills.Config.Account != "" {
+		keystorePath = worldskills.Config.Account
+		userLoadExist = true
+	}
+	if !userLoadExist {
+		return worldskills.WithExit(worldskills.EXIT_USAGE, errors.New("-loaduser:<hex>, -keystore:<path> or account of profile is required"))
+	}
+	if keystorePath != "" {
+		passphrase, err := worldskills.ReadPassphrase(passfile, false)
+		if err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, fmt.Errorf("read passphrase: %w", err))
+		}
+		priv, err := worldskills.ReadKeystore(keystorePath, passphrase)
+		if err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, fmt.Errorf("decrypt keystore %s: %w", keystorePath, err))
+		}
+		userLoadStr = hex.EncodeToString(crypto.FromECDSA(priv))
+	}
+	var err error
+	User, err = worldskills.LoadUser(userLoadStr)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_USER, err)
+	}
+	return nil
+}
+
+// Index is started by the first listing, other commands do not read
+// every record of contract.
+func startIndex() error {
+	if worldskills.Index != nil {
+		return nil
+	}
+	index, err := worldskills.StartIndexer()
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("start indexer: %w", err))
+	}
+	worldskills.Index = index
+	return nil
+}
+
+func keystoreCreate(dir string, passfile string) error {
+	passphrase, err := worldskills.ReadPassphrase(passfile, true)
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	address, err := worldskills.NewKeystore(dir, passphrase)
+	if err != nil {
+		return fmt.Errorf("create keystore: %w", err)
+	}
+	fmt.Println("Address:", address.Hex())
+	return nil
+}
+
+func keystoreImport(dir string, passfile string) error {
+	purse, err := worldskills.InputPassword("Private key: ")
+	if err != nil {
+		return fmt.Errorf("read private key: %w", err)
+	}
+	passphrase, err := worldskills.ReadPassphrase(passfile, true)
+	if err != nil {
+		return fmt.Errorf("read passphrase: %w", err)
+	}
+	address, err := worldskills.ImportKeystore(dir, purse, passphrase)
+	if err != nil {
+		return fmt.Errorf("import keystore: %w", err)
+	}
+	fmt.Println("Address:", address.Hex())
+	return nil
+}
+
+// Run command from command line and exit with its code,
+// or read commands from stdin until /exit.
+func main() {
+	if err := setup(); err != nil {
+		worldskills.Fatal(err)
+	}
+	if len(Command) != 0 {
+		if err := runCommand(Command); err != nil {
+			worldskills.Fatal(err)
+		}
+		os.Exit(0)
+	}
+	Interactive = true
+	if err := repl(); err != nil {
+		worldskills.Fatal(err)
+	}
+}
+
+// Read commands from Input until /exit or end of input.
+func repl() error {
+	for {
+		message, err := inputString("> ")
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("read command: %w", err))
+		}
+		message = strings.TrimSpace(message)
+		if message == "" {
+			continue
+		}
+		splited := strings.Fields(strings.TrimPrefix(message, "/"))
+		switch splited[0] {
+		case "exit":
+			return nil
+		case "help":
+			printUsage()
+			continue
+		}
+		if err := runCommand(splited); err != nil {
+			fmt.Println("failed:", err)
+		}
+		fmt.Println()
+	}
+}
+
+// Find command by the longest matching words, check number of its
+// arguments, run it and print result.
+func runCommand(splited []string) error {
+	for n := len(splited); n > 0; n-- {
+		name := strings.Join(splited[:n], " ")
+		command, ok := commands[name]
+		if !ok {
+			continue
+		}
+		args := splited[n:]
+		if len(args) != len(command.Args) {
+			return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("usage: %s %s", name, strings.Join(command.Args, " ")))
+		}
+		result, err := command.Run(args)
+		if result != nil {
+			if err := printResult(os.Stdout, Output, result); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+	return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("command undefined: %s", strings.Join(splited, " ")))
+}
+
+func printUsage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Println("/"+name, strings.Join(commands[name].Args, " "))
+	}
+	fmt.Println()
+}
+
+func parseNumber(name string, value string) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(value, 10)
+	if !ok || num.Sign() < 0 {
+		return nil, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("%s %q is not a number", name, value))
+	}
+	return num, nil
+}
+
+func parseAddress(name string, value string) (common.Address, error) {
+	if !common.IsHexAddress(value) {
+		return common.Address{}, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("%s %q is invalid", name, value))
+	}
+	return common.HexToAddress(value), nil
+}
+
+// Check method with Preflight, send it and wait for receipt.
+// Refused and reverted transactions end with EXIT_TX, failed node calls
+// and gas estimates with EXIT_CONNECT.
+func transact(value *big.Int, method string, send func(*bind.TransactOpts) (*types.Transaction, error), args ...interface{}) (interface{}, error) {
+	auth, err := worldskills.ResetAuth(User)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	auth.Value = value
+	if err := worldskills.Preflight(User, auth, method, args...); err != nil {
+		var refused *worldskills.RefusedError
+		if errors.As(err, &refused) {
+			return nil, worldskills.WithExit(worldskills.EXIT_TX, err)
+		}
+		return nil, worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	if Output == "text" {
+		fmt.Println(worldskills.GasString(auth))
+	}
+	tx, err := worldskills.SendTx(auth, send)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_TX, err)
+	}
+	if Output == "text" {
+		fmt.Println("Tx:", tx.Hash().Hex())
+	}
+	receipt, err := worldskills.WaitTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !receipt.Success {
+		return receipt, worldskills.WithExit(worldskills.EXIT_TX, fmt.Errorf("tx %s reverted", tx.Hash().Hex()))
+	}
+	return receipt, nil
+}
+
+func chainCreateEstate(args []string) (interface{}, error) {
+	squere, err := parseNumber("squere", args[2])
+	if err != nil {
+		return nil, err
+	}
+	usefulSquere, err := parseNumber("usefulSquere", args[3])
+	if err != nil {
+		return nil, err
+	}
+	address := User.AddressEth
+	if args[0] != "my" {
+		if address, err = parseAddress("address", args[0]); err != nil {
+			return nil, err
+		}
+	}
+	return transact(nil, "create_estate", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateEstate(
+			auth,
+			address,
+			args[1],
+			squere,
+			usefulSquere,
+		)
+	}, address, args[1], squere, usefulSquere)
+}
+
+func chainCreatePresent(args []string) (interface{}, error) {
+	estateId, err := parseNumber("id_estate", args[0])
+	if err != nil {
+		return nil, err
+	}
+	address, err := parseAddress("address", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "create_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreatePresent(
+			auth,
+			estateId,
+			address,
+		)
+	}, estateId, address)
+}
+
+func chainCancelPresent(args []string) (interface{}, error) {
+	num, err := parseNumber("id_present", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "cancel_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelPresent(
+			auth,
+			num,
+		)
+	}, num)
+}
+
+func chainConfirmPresent(args []string) (interface{}, error) {
+	presentNumber, err := parseNumber("id_present", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "confirm_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ConfirmPresent(
+			auth,
+			presentNumber,
+		)
+	}, presentNumber)
+}
+
+func chainCreateSale(args []string) (interface{}, error) {
+	estateId, err := parseNumber("id_estate", args[0])
+	if err != nil {
+		return nil, err
+	}
+	price, err := parseNumber("price", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateSale(
+			auth,
+			estateId,
+			price,
+		)
+	}, estateId, price)
+}
+
+func chainBidSale(args []string) (interface{}, error) {
+	saleNumber, err := parseNumber("id_sale", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseNumber("wei", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return transact(value, "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CheckToBuy(
+			auth,
+			saleNumber,
+		)
+	}, saleNumber)
+}
+
+func chainWithdrawSale(args []string) (interface{}, error) {
+	saleNumber, err := parseNumber("id_sale", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "cancel_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelToBuy(
+			auth,
+			saleNumber,
+		)
+	}, saleNumber)
+}
+
+func chainConfirmSale(args []string) (interface{}, error) {
+	saleNumber, err := parseNumber("id_sale", args[0])
+	if err != nil {
+		return nil, err
+	}
+	saleTo, err := parseNumber("id_customer", args[1])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "confirm_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ConfirmSale(
+			auth,
+			saleNumber,
+			saleTo,
+		)
+	}, saleNumber, saleTo)
+}
+
+func chainCancelSale(args []string) (interface{}, error) {
+	saleNumber, err := parseNumber("id_sale", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "cancel_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelSale(
+			auth,
+			saleNumber,
+		)
+	}, saleNumber)
+}
+
+func chainCreateRent(args []string) (interface{}, error) {
+	estateId, err := parseNumber("id_estate", args[0])
+	if err != nil {
+		return nil, err
+	}
+	days, err := parseNumber("days", args[1])
+	if err != nil {
+		return nil, err
+	}
+	price, err := parseNumber("price", args[2])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateRent(
+			auth,
+			estateId,
+			days,
+			price,
+		)
+	}, estateId, days, price)
+}
+
+func chainTakeRent(args []string) (interface{}, error) {
+	rentId, err := parseNumber("id_rent", args[0])
+	if err != nil {
+		return nil, err
+	}
+	value, err := worldskills.RentValue(User, rentId)
+	if err != nil {
+		return nil, err
+	}
+	return transact(value, "to_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ToRent(
+			auth,
+			rentId,
+		)
+	}, rentId)
+}
+
+func chainCancelRent(args []string) (interface{}, error) {
+	rentId, err := parseNumber("id_rent", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "cancel_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelRent(
+			auth,
+			rentId,
+		)
+	}, rentId)
+}
+
+func chainFinishRent(args []string) (interface{}, error) {
+	rentId, err := parseNumber("id_rent", args[0])
+	if err != nil {
+		return nil, err
+	}
+	return transact(nil, "finish_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.FinishRent(
+			auth,
+			rentId,
+		)
+	}, rentId)
+}
+
+func txReplace(args []string) (interface{}, error) {
+	return resendTx(args[0], false)
+}
+
+func txCancel(args []string) (interface{}, error) {
+	return resendTx(args[0], true)
+}
+
+// Replace stuck tx of user with higher gas price and wait for the new one.
+func resendTx(hash string, cancel bool) (interface{}, error) {
+	if len(strings.TrimPrefix(hash, "0x")) != 2*common.HashLength {
+		return nil, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("tx_hash %q is invalid", hash))
+	}
+	tx, err := worldskills.ReplaceTx(User, common.HexToHash(hash), cancel)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_TX, err)
+	}
+	if Output == "text" {
+		fmt.Println("Tx:", tx.Hash().Hex())
+	}
+	receipt, err := worldskills.WaitTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if !receipt.Success {
+		return receipt, worldskills.WithExit(worldskills.EXIT_TX, fmt.Errorf("tx %s reverted", tx.Hash().Hex()))
+	}
+	return receipt, nil
+}
+
+// Listings are answered from Index.
+func chainGet(args []string) (interface{}, error) {
+	if err := startIndex(); err != nil {
+		return nil, err
+	}
+	var (
+		filter = args[1]
+		list = []interface{}{}
+	)
+	switch args[0] {
+	case "estates":
+		for _, data := range worldskills.Index.Estates() {
+			if !worldskills.MatchAddress(filter, User, data.Owner) {
+				continue
+			}
+			list = append(list, data)
+		}
+	case "presents":
+		for _, data := range worldskills.Index.Presents() {
+			if data.Finished || !worldskills.MatchAddress(filter, User, data.AddressFrom, data.AddressTo) {
+				continue
+			}
+			list = append(list, data)
+		}
+	case "sales":
+		for _, data := range worldskills.Index.Sales() {
+			if data.Finished || !worldskills.MatchAddress(filter, User, append([]common.Address{data.Owner}, data.Customers...)...) {
+				continue
+			}
+			list = append(list, data)
+		}
+	case "rents":
+		for _, data := range worldskills.Index.Rents() {
+			if data.Finished || !worldskills.MatchAddress(filter, User, data.OwnerAddress, data.RenterAddress) {
+				continue
+			}
+			list = append(list, struct{
+				*worldskills.Rent
+				DeadlineTime string
+			}{data, worldskills.RentDeadline(data)})
+		}
+	default:
+		return nil, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("undefined category %s", args[0]))
+	}
+	return list, nil
+}
+
+func chainEvents(args []string) (interface{}, error) {
+	from, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("from_block %q is not a number", args[0]))
+	}
+	events, err := worldskills.FilterEvents(from, nil)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []*worldskills.EventType{}
+	}
+	return events, nil
+}
+
+func chainHistoryEstate(args []string) (interface{}, error) {
+	estateId, err := parseNumber("id_estate", args[0])
+	if err != nil {
+		return nil, err
+	}
+	history, err := worldskills.GetEstateHistory(estateId)
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []*worldskills.HistoryType{}
+	}
+	return history, nil
+}
+
+// Print events of new blocks until enter is pressed in REPL,
+// or until interrupt when run from command line.
+func chainWatchEvents(args []string) (interface{}, error) {
+	var (
+		sink = make(chan *worldskills.EventType)
+		stop = make(chan struct{})
+	)
+	if err := worldskills.WatchEvents(sink, stop); err != nil {
+		return nil, err
+	}
+	if Interactive {
+		go func() {
+			inputString("")
+			close(stop)
+		}()
+		fmt.Println("Press enter to stop ...")
+	} else {
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			signal.Stop(interrupt)
+			close(stop)
+		}()
+	}
+	for {
+		select {
+		case event := <-sink:
+			if err := printResult(os.Stdout, Output, event); err != nil {
+				return nil, err
+			}
+		case <-stop:
+			return nil, nil
+		}
+	}
+}
+
+func userAddress(args []string) (interface{}, error) {
+	return struct{ Address string }{User.AddressHex}, nil
+}
+
+func userPurse(args []string) (interface{}, error) {
+	return struct{ Purse string }{User.Purse}, nil
+}
+
+func userBalance(args []string) (interface{}, error) {
+	balance, err := worldskills.ClientETH.BalanceAt(context.Background(), User.AddressEth, nil)
+	if err != nil {
+		return nil, worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	return struct{ Balance *big.Int }{balance}, nil
+}
+
+// Print begin and read line of Input, io.EOF at end of input.
+func inputString(begin string) (string, error) {
+	fmt.Print(begin)
+	if !Input.Scan() {
+		if err := Input.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return Input.Text(), nil
+}
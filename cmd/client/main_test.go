@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+	"math/big"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+var backends = []string{"memory", "contract"}
+
+// Run command of REPL as user, it must succeed.
+func run(t *testing.T, user *worldskills.UserType, line string) {
+	t.Helper()
+	User = user
+	if err := runCommand(strings.Fields(line)); err != nil {
+		t.Fatalf("%s: %v", line, err)
+	}
+}
+
+// Run command of REPL as user, it must fail with exit code.
+func runFails(t *testing.T, user *worldskills.UserType, line string, code int) {
+	t.Helper()
+	User = user
+	err := runCommand(strings.Fields(line))
+	var exitErr *worldskills.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != code {
+		t.Fatalf("%s: %v, want exit code %d", line, err, code)
+	}
+}
+
+// Estate is presented, sold and rented with commands of REPL.
+func TestCommandLifecycles(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			users := chaintest.Setup(t, backend)
+			Output = "json"
+			first, second := users.First.AddressHex, users.Second.AddressHex
+
+			runFails(t, users.Admin, "chain create estate 0x123 flat 100 80", worldskills.EXIT_USAGE)
+			run(t, users.Admin, "chain create estate "+first+" flat 100 80")
+			if worldskills.Index != nil {
+				t.Fatal("index is started before the first listing")
+			}
+			runFails(t, users.First, "chain create present 0 second", worldskills.EXIT_USAGE)
+			run(t, users.First, "chain create present 0 "+second)
+			run(t, users.Second, "chain confirm present 0")
+
+			run(t, users.Second, "chain create sale 0 1000")
+			runFails(t, users.First, "chain bid sale 0 999", worldskills.EXIT_TX)
+			run(t, users.First, "chain bid sale 0 1000")
+			runFails(t, users.Second, "chain confirm sale 0 -1", worldskills.EXIT_USAGE)
+			runFails(t, users.Second, "chain confirm sale 0", worldskills.EXIT_USAGE)
+			run(t, users.Second, "chain confirm sale 0 0")
+
+			run(t, users.First, "chain create rent 0 0 500")
+			run(t, users.Second, "chain take rent 0")
+			chaintest.Mine(t)
+			run(t, users.First, "chain finish rent 0")
+
+			run(t, users.First, "chain get estates my")
+			if worldskills.Index == nil {
+				t.Fatal("index is not started by listing")
+			}
+			estates := worldskills.Index.Estates()
+			if len(estates) != 1 || estates[0].Owner != users.First.AddressEth || estates[0].RentStatus {
+				t.Fatalf("estates of index %+v, want one of first user without rent", estates)
+			}
+			rents := worldskills.Index.Rents()
+			if len(rents) != 1 || !rents[0].Finished || rents[0].Money.Cmp(big.NewInt(500)) != 0 {
+				t.Fatalf("rents of index %+v, want one finished for 500 wei", rents)
+			}
+			run(t, users.First, "chain history estate 0")
+		})
+	}
+}
+
+// REPL reads commands until end of input, failed commands do not stop it.
+func TestREPL(t *testing.T) {
+	users := chaintest.Setup(t, "memory")
+	User = users.Admin
+	Output = "json"
+	Input = bufio.NewScanner(strings.NewReader("user address\n\n/chain take rent x\nchain create estate my flat 100 80\n"))
+	if err := repl(); err != nil {
+		t.Fatal(err)
+	}
+	number, err := worldskills.Backend.EstatesNumber(users.Admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number.Int64() != 1 {
+		t.Fatalf("%s estates after REPL, want 1", number)
+	}
+
+	Input = bufio.NewScanner(strings.NewReader("/exit\nchain create estate my flat 100 80\n"))
+	if err := repl(); err != nil {
+		t.Fatal(err)
+	}
+	if number, _ := worldskills.Backend.EstatesNumber(users.Admin); number.Int64() != 1 {
+		t.Fatalf("command after /exit is run, %s estates", number)
+	}
+}
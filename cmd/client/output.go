@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"fmt"
+	"strings"
+	"reflect"
+	"encoding/json"
+	"text/tabwriter"
+)
+
+// Print result of command as json, table or text. Lists are printed
+// item by item, structs by exported fields, embedded structs are flattened.
+func printResult(w io.Writer, format string, result interface{}) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(result, "", "\t")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "table":
+		return printTable(w, resultItems(result))
+	default:
+		for i, item := range resultItems(result) {
+			if _, ok := item.(fmt.Stringer); !ok && i > 0 {
+				fmt.Fprintln(w)
+			}
+			if err := printText(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Slice is split to items, other value is the only item.
+func resultItems(result interface{}) []interface{} {
+	value := reflect.ValueOf(result)
+	if value.Kind() != reflect.Slice {
+		return []interface{}{result}
+	}
+	items := make([]interface{}, value.Len())
+	for i := range items {
+		items[i] = value.Index(i).Interface()
+	}
+	return items
+}
+
+func printText(w io.Writer, item interface{}) error {
+	if stringer, ok := item.(fmt.Stringer); ok {
+		_, err := fmt.Fprintln(w, stringer.String())
+		return err
+	}
+	names, values := resultFields(item)
+	if names == nil {
+		_, err := fmt.Fprintln(w, item)
+		return err
+	}
+	for i := range names {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", names[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Header is taken from the first item.
+func printTable(w io.Writer, items []interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	names, _ := resultFields(items[0])
+	if names == nil {
+		names = []string{"Value"}
+	}
+	fmt.Fprintln(tw, strings.Join(names, "\t"))
+	for _, item := range items {
+		_, values := resultFields(item)
+		if values == nil {
+			values = []string{fmt.Sprint(item)}
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+// Names and printed values of exported fields, nil if item is not a struct.
+func resultFields(item interface{}) ([]string, []string) {
+	value := reflect.ValueOf(item)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var names, values []string
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous {
+			embedNames, embedValues := resultFields(value.Field(i).Interface())
+			names = append(names, embedNames...)
+			values = append(values, embedValues...)
+			continue
+		}
+		names = append(names, field.Name)
+		values = append(values, fmt.Sprint(value.Field(i).Interface()))
+	}
+	return names, values
+}
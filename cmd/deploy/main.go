@@ -0,0 +1,205 @@
+package main
+
+import (
+	"os"
+	"io/ioutil"
+	"fmt"
+	"errors"
+	"context"
+	"strings"
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/common"
+	contract "github.com/number571/contract-interfaces/contracts"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+const (
+	DEFAULT_BIN = "build/WorldSkills.bin"
+)
+
+var (
+	User *worldskills.UserType
+	// Command from command line: empty to deploy,
+	// "wait <tx_hash>" or "verify <address>".
+	Command []string
+	// Creation code built by solc, compared by verify.
+	BinFile = DEFAULT_BIN
+)
+
+// Parse command line, connect to chain and load user.
+// Contract is not needed, so only config and node are set up.
+// wait and verify do not send transactions and need no user.
+func setup() error {
+	var (
+		userLoadStr = ""
+		userLoadExist = false
+		keystorePath = ""
+		passfile = ""
+	)
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		switch {
+		case strings.HasPrefix(arg, "-loaduser:"):
+			userLoadStr = strings.Replace(arg, "-loaduser:", "", 1)
+			userLoadExist = true
+		case strings.HasPrefix(arg, "-keystore:"):
+			keystorePath = strings.Replace(arg, "-keystore:", "", 1)
+			userLoadExist = true
+		case strings.HasPrefix(arg, "-passfile:"):
+			passfile = strings.Replace(arg, "-passfile:", "", 1)
+		case strings.HasPrefix(arg, "-bin:"):
+			BinFile = strings.Replace(arg, "-bin:", "", 1)
+		case !strings.HasPrefix(arg, "-"):
+			Command = append(Command, arg)
+		}
+	}
+	switch {
+	case len(Command) == 0:
+	case len(Command) == 2 && (Command[0] == "wait" || Command[0] == "verify"):
+	default:
+		return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("unknown command %q, use wait <tx_hash> or verify <address>", strings.Join(Command, " ")))
+	}
+	var err error
+	worldskills.Config, err = worldskills.LoadConfig(os.Args[1:])
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("load config: %w", err))
+	}
+	if len(Command) == 0 && !userLoadExist {
+		if worldskills.Config.Account == "" {
+			return worldskills.WithExit(worldskills.EXIT_USAGE, errors.New("-loaduser:<hex>, -keystore:<path> or account of profile is required"))
+		}
+		keystorePath = worldskills.Config.Account
+	}
+	worldskills.ClientETH, err = worldskills.ConnectToETH(worldskills.Config.RPC)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	if len(Command) != 0 {
+		return nil
+	}
+	if keystorePath != "" {
+		passphrase, err := worldskills.ReadPassphrase(passfile, false)
+		if err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, fmt.Errorf("read passphrase: %w", err))
+		}
+		priv, err := worldskills.ReadKeystore(keystorePath, passphrase)
+		if err != nil {
+			return worldskills.WithExit(worldskills.EXIT_USER, fmt.Errorf("decrypt keystore %s: %w", keystorePath, err))
+		}
+		userLoadStr = hex.EncodeToString(crypto.FromECDSA(priv))
+	}
+	User, err = worldskills.LoadUser(userLoadStr)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_USER, err)
+	}
+	worldskills.Nonces = worldskills.NewNonceManager(worldskills.ClientETH)
+	return nil
+}
+
+// Deploy contract, wait for it and record it in deployments file.
+// wait records deployment sent before, verify compares code on chain
+// with the code built by solc.
+func main() {
+	if err := setup(); err != nil {
+		worldskills.Fatal(err)
+	}
+	var err error
+	switch {
+	case len(Command) == 0:
+		err = deploy()
+	case Command[0] == "wait":
+		err = wait(Command[1])
+	case Command[0] == "verify":
+		err = verify(Command[1])
+	}
+	if err != nil {
+		worldskills.Fatal(err)
+	}
+}
+
+func deploy() error {
+	auth, err := worldskills.ResetAuth(User)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	if err := worldskills.SetGas(auth, "deploy", nil, common.FromHex(contract.ContractBin)); err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONNECT, err)
+	}
+	fmt.Println(worldskills.GasString(auth))
+	var address common.Address
+	tx, err := worldskills.SendTx(auth, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		var (
+			tx *types.Transaction
+			err error
+		)
+		address, tx, _, err = contract.DeployContract(auth, worldskills.ClientETH)
+		return tx, err
+	})
+
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("deploy contract: %w", err))
+	}
+
+	fmt.Println(address.Hex())
+	fmt.Println(tx.Hash().Hex())
+
+	return record(tx)
+}
+
+// Attach to deployment sent before, e.g. when deploy timed out.
+func wait(hash string) error {
+	if len(strings.TrimPrefix(hash, "0x")) != 2*common.HashLength {
+		return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("tx_hash %q is invalid", hash))
+	}
+	tx, _, err := worldskills.ClientETH.TransactionByHash(context.Background(), common.HexToHash(hash))
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONNECT, fmt.Errorf("get tx %s: %w", hash, err))
+	}
+	return record(tx)
+}
+
+func record(tx *types.Transaction) error {
+	deployment, err := worldskills.WaitDeployment(tx)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("%w, run deploy wait %s later", err, tx.Hash().Hex()))
+	}
+	fmt.Printf("Block: %d, Network: %s, Deployer: %s\n", deployment.Block, deployment.NetworkId, deployment.Deployer.Hex())
+	if err := worldskills.SaveDeployment(worldskills.Config.Deployments, deployment); err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, err)
+	}
+	return nil
+}
+
+func verify(address string) error {
+	if !common.IsHexAddress(address) {
+		return worldskills.WithExit(worldskills.EXIT_USAGE, fmt.Errorf("address %q is invalid", address))
+	}
+	data, err := ioutil.ReadFile(BinFile)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("read %s: %w", BinFile, err))
+	}
+	creation, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("read %s: %w", BinFile, err))
+	}
+	code, err := worldskills.ClientETH.CodeAt(context.Background(), common.HexToAddress(address), nil)
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONNECT, fmt.Errorf("get code of contract %s: %w", address, err))
+	}
+	if len(code) == 0 {
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("no contract code at address %s", address))
+	}
+	match, metadata := worldskills.CompareCode(code, creation)
+	switch {
+	case !match:
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("code at %s differs from %s", address, BinFile))
+	case metadata:
+		fmt.Println("Code matches", BinFile, "except metadata (sources or compiler settings differ)")
+	default:
+		fmt.Println("Code matches", BinFile)
+	}
+	return nil
+}
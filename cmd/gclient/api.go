@@ -0,0 +1,548 @@
+package main
+
+import (
+	"fmt"
+	"errors"
+	"context"
+	"strings"
+	"net/http"
+	"math/big"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+const (
+	API_PREFIX = "/api/v1/"
+)
+
+// Error of API handler with HTTP status, written as {"error": "..."}.
+type apiError struct {
+	Status int
+	Err error
+}
+
+func (e *apiError) Error() string {
+	return e.Err.Error()
+}
+
+func apiErrorf(status int, format string, args ...interface{}) error {
+	return &apiError{Status: status, Err: fmt.Errorf(format, args...)}
+}
+
+// Result of write action.
+type apiTxResult struct {
+	TxHash common.Hash `json:"tx_hash"`
+	BlockNumber *big.Int `json:"block_number"`
+	GasUsed uint64 `json:"gas_used"`
+	Success bool `json:"success"`
+	MaxCost *big.Int `json:"max_cost"`
+}
+
+// Gas set by Preflight, fees are either legacy price or EIP-1559 fees.
+type apiEstimateResult struct {
+	Gas uint64 `json:"gas"`
+	GasPrice *big.Int `json:"gas_price,omitempty"`
+	MaxFee *big.Int `json:"max_fee,omitempty"`
+	PriorityFee *big.Int `json:"priority_fee,omitempty"`
+	MaxCost *big.Int `json:"max_cost"`
+}
+
+type apiHandler func(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error)
+
+// Routes of API by first part of path after API_PREFIX.
+var apiRoutes = map[string]apiHandler{
+	"account": apiAccount,
+	"estates": apiEstates,
+	"presents": apiPresents,
+	"sales": apiSales,
+	"rents": apiRents,
+}
+
+// Serve API_PREFIX. Session is taken from the same cookie as pages use,
+// POST /api/v1/login creates it.
+func apiServe(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, API_PREFIX), "/"), "/")
+	switch path[0] {
+	case "login":
+		if r.Method != "POST" {
+			apiWrite(w, nil, apiErrorf(http.StatusMethodNotAllowed, "method %s is not allowed", r.Method))
+			return
+		}
+		user, err := apiLogin(r)
+		if err != nil {
+			apiWrite(w, nil, err)
+			return
+		}
+		Sessions.Create(w, user)
+		apiWrite(w, map[string]string{"address": user.AddressHex}, nil)
+		return
+	case "logout":
+		Sessions.Delete(w, r)
+		apiWrite(w, map[string]string{}, nil)
+		return
+	}
+	handler, ok := apiRoutes[path[0]]
+	if !ok {
+		apiWrite(w, nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path))
+		return
+	}
+	user := Sessions.User(r)
+	if user == nil {
+		apiWrite(w, nil, apiErrorf(http.StatusUnauthorized, "login required"))
+		return
+	}
+	result, err := handler(user, r, path[1:])
+	apiWrite(w, result, err)
+}
+
+func apiWrite(w http.ResponseWriter, result interface{}, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		status := http.StatusInternalServerError
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			status = apiErr.Status
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// Login with {"private": "<hex>"} or multipart form like /login page.
+func apiLogin(r *http.Request) (*worldskills.UserType, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		r.ParseMultipartForm(KEYSTORE_SIZE)
+		user, err := loadUserKeystore(r)
+		if err != nil {
+			return nil, apiErrorf(http.StatusUnauthorized, "%w", err)
+		}
+		return user, nil
+	}
+	var body struct {
+		Private string `json:"private"`
+	}
+	if err := apiDecode(r, &body); err != nil {
+		return nil, err
+	}
+	user, err := worldskills.LoadUser(body.Private)
+	if err != nil {
+		return nil, apiErrorf(http.StatusUnauthorized, "%w", err)
+	}
+	return user, nil
+}
+
+func apiDecode(r *http.Request, body interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		return apiErrorf(http.StatusBadRequest, "decode body: %w", err)
+	}
+	return nil
+}
+
+// Parse decimal number from body or path, name is used in error.
+func apiNumber(name string, value string) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(value, 10)
+	if !ok || num.Sign() < 0 {
+		return nil, apiErrorf(http.StatusBadRequest, "%s %q is not a number", name, value)
+	}
+	return num, nil
+}
+
+// Id of record from path, 404 if contract has no such record. Records
+// are counted on contract, so the ones mined after the last poll of
+// Index are found too.
+func apiIndex(user *worldskills.UserType, record string, value string, number func(*worldskills.UserType) (*big.Int, error)) (*big.Int, error) {
+	index, err := apiNumber(record, value)
+	if err != nil {
+		return nil, err
+	}
+	count, err := number(user)
+	if err != nil {
+		return nil, apiErrorf(http.StatusBadGateway, "count %ss: %w", record, err)
+	}
+	if index.Cmp(count) >= 0 {
+		return nil, apiErrorf(http.StatusNotFound, "%s %s does not exist", record, index)
+	}
+	return index, nil
+}
+
+// Record of contract by id, 502 if node can not be read.
+func apiRecord(record string, get func() (interface{}, error)) (interface{}, error) {
+	data, err := get()
+	if err != nil {
+		return nil, apiErrorf(http.StatusBadGateway, "get %s: %w", record, err)
+	}
+	return data, nil
+}
+
+func apiMethod(r *http.Request, method string) error {
+	if r.Method != method {
+		return apiErrorf(http.StatusMethodNotAllowed, "method %s is not allowed", r.Method)
+	}
+	return nil
+}
+
+// Check method with Preflight, send it and wait for receipt. With
+// ?dry_run=1 gas and max cost are answered and nothing is sent.
+// Call refused by contract is 422, reverted tx is 409, failed node call
+// or gas estimate is 502.
+func apiTransact(user *worldskills.UserType, r *http.Request, value *big.Int, method string, send func(*bind.TransactOpts) (*types.Transaction, error), args ...interface{}) (interface{}, error) {
+	auth, err := worldskills.ResetAuth(user)
+	if err != nil {
+		return nil, apiErrorf(http.StatusBadGateway, "%w", err)
+	}
+	auth.Value = value
+	if err := worldskills.Preflight(user, auth, method, args...); err != nil {
+		var refused *worldskills.RefusedError
+		if errors.As(err, &refused) {
+			return nil, apiErrorf(http.StatusUnprocessableEntity, "%w", err)
+		}
+		return nil, apiErrorf(http.StatusBadGateway, "%w", err)
+	}
+	if r.URL.Query().Get("dry_run") == "1" {
+		return &apiEstimateResult{
+			Gas: auth.GasLimit,
+			GasPrice: auth.GasPrice,
+			MaxFee: auth.GasFeeCap,
+			PriorityFee: auth.GasTipCap,
+			MaxCost: worldskills.GasCost(auth),
+		}, nil
+	}
+	tx, err := worldskills.SendTx(auth, send)
+	if err != nil {
+		return nil, apiErrorf(http.StatusBadGateway, "%w", err)
+	}
+	receipt, err := worldskills.WaitTxUnlocked(tx)
+	if err != nil {
+		return nil, apiErrorf(http.StatusGatewayTimeout, "%w", err)
+	}
+	if !receipt.Success {
+		return nil, apiErrorf(http.StatusConflict, "tx %s reverted in block %s", receipt.TxHash.Hex(), receipt.BlockNumber)
+	}
+	return &apiTxResult{
+		TxHash: receipt.TxHash,
+		BlockNumber: receipt.BlockNumber,
+		GasUsed: receipt.GasUsed,
+		Success: receipt.Success,
+		MaxCost: tx.Cost(),
+	}, nil
+}
+
+// GET /account
+func apiAccount(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error) {
+	if err := apiMethod(r, "GET"); err != nil {
+		return nil, err
+	}
+	if len(path) != 0 {
+		return nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path)
+	}
+	balance, err := worldskills.ClientETH.BalanceAt(context.Background(), user.AddressEth, nil)
+	if err != nil {
+		return nil, apiErrorf(http.StatusBadGateway, "get balance: %w", err)
+	}
+	return map[string]interface{}{
+		"address": user.AddressHex,
+		"balance": balance,
+	}, nil
+}
+
+// GET /estates?address=my|all|<hex>, GET /estates/<id>,
+// GET /estates/<id>/history, POST /estates {"info", "squere", "useful_squere"}.
+func apiEstates(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		if r.Method == "POST" {
+			var body struct {
+				Info string `json:"info"`
+				Squere string `json:"squere"`
+				UsefulSquere string `json:"useful_squere"`
+			}
+			if err := apiDecode(r, &body); err != nil {
+				return nil, err
+			}
+			squere, err := apiNumber("squere", body.Squere)
+			if err != nil {
+				return nil, err
+			}
+			usefulSquere, err := apiNumber("useful_squere", body.UsefulSquere)
+			if err != nil {
+				return nil, err
+			}
+			return apiTransact(user, r, nil, "create_estate", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateEstate(auth, user.AddressEth, body.Info, squere, usefulSquere)
+			}, user.AddressEth, body.Info, squere, usefulSquere)
+		}
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		list := []*worldskills.Estate{}
+		for _, estate := range worldskills.Index.Estates() {
+			if worldskills.MatchAddress(apiFilter(r), user, estate.Owner) {
+				list = append(list, estate)
+			}
+		}
+		return list, nil
+	}
+	if err := apiMethod(r, "GET"); err != nil {
+		return nil, err
+	}
+	index, err := apiIndex(user, "estate", path[0], worldskills.Backend.EstatesNumber)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(path) == 1:
+		return apiRecord("estate", func() (interface{}, error) {
+			return worldskills.Backend.GetEstates(user, index)
+		})
+	case len(path) == 2 && path[1] == "history":
+		history, err := worldskills.GetEstateHistory(index)
+		if err != nil {
+			return nil, apiErrorf(http.StatusBadGateway, "%w", err)
+		}
+		return history, nil
+	}
+	return nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path)
+}
+
+// GET /presents, GET /presents/<id>, POST /presents {"estate_id", "address"},
+// POST /presents/<id>/cancel|confirm.
+func apiPresents(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		if r.Method == "POST" {
+			var body struct {
+				EstateId string `json:"estate_id"`
+				Address string `json:"address"`
+			}
+			if err := apiDecode(r, &body); err != nil {
+				return nil, err
+			}
+			estateId, err := apiNumber("estate_id", body.EstateId)
+			if err != nil {
+				return nil, err
+			}
+			if !common.IsHexAddress(body.Address) {
+				return nil, apiErrorf(http.StatusBadRequest, "address %q is invalid", body.Address)
+			}
+			address := common.HexToAddress(body.Address)
+			return apiTransact(user, r, nil, "create_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreatePresent(auth, estateId, address)
+			}, estateId, address)
+		}
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		list := []*worldskills.Present{}
+		for _, present := range worldskills.Index.Presents() {
+			if !present.Finished && worldskills.MatchAddress(apiFilter(r), user, present.AddressFrom, present.AddressTo) {
+				list = append(list, present)
+			}
+		}
+		return list, nil
+	}
+	index, err := apiIndex(user, "present", path[0], worldskills.Backend.PresentsNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		return apiRecord("present", func() (interface{}, error) {
+			return worldskills.Backend.GetPresents(user, index)
+		})
+	}
+	if err := apiMethod(r, "POST"); err != nil {
+		return nil, err
+	}
+	switch path[1] {
+	case "cancel":
+		return apiTransact(user, r, nil, "cancel_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CancelPresent(auth, index)
+		}, index)
+	case "confirm":
+		return apiTransact(user, r, nil, "confirm_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.ConfirmPresent(auth, index)
+		}, index)
+	}
+	return nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path)
+}
+
+// GET /sales, GET /sales/<id>, POST /sales {"estate_id", "price"},
+// POST /sales/<id>/bid {"value"}, /withdraw, /confirm {"customer"}, /cancel.
+func apiSales(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		if r.Method == "POST" {
+			var body struct {
+				EstateId string `json:"estate_id"`
+				Price string `json:"price"`
+			}
+			if err := apiDecode(r, &body); err != nil {
+				return nil, err
+			}
+			estateId, err := apiNumber("estate_id", body.EstateId)
+			if err != nil {
+				return nil, err
+			}
+			price, err := apiNumber("price", body.Price)
+			if err != nil {
+				return nil, err
+			}
+			return apiTransact(user, r, nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateSale(auth, estateId, price)
+			}, estateId, price)
+		}
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		list := []*worldskills.Sale{}
+		for _, sale := range worldskills.Index.Sales() {
+			if !sale.Finished && worldskills.MatchAddress(apiFilter(r), user, append([]common.Address{sale.Owner}, sale.Customers...)...) {
+				list = append(list, sale)
+			}
+		}
+		return list, nil
+	}
+	index, err := apiIndex(user, "sale", path[0], worldskills.Backend.SalesNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		return apiRecord("sale", func() (interface{}, error) {
+			return worldskills.Backend.GetSales(user, index)
+		})
+	}
+	if err := apiMethod(r, "POST"); err != nil {
+		return nil, err
+	}
+	switch path[1] {
+	case "bid":
+		var body struct {
+			Value string `json:"value"`
+		}
+		if err := apiDecode(r, &body); err != nil {
+			return nil, err
+		}
+		value, err := apiNumber("value", body.Value)
+		if err != nil {
+			return nil, err
+		}
+		return apiTransact(user, r, value, "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CheckToBuy(auth, index)
+		}, index)
+	case "withdraw":
+		return apiTransact(user, r, nil, "cancel_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CancelToBuy(auth, index)
+		}, index)
+	case "confirm":
+		var body struct {
+			Customer string `json:"customer"`
+		}
+		if err := apiDecode(r, &body); err != nil {
+			return nil, err
+		}
+		saleTo, err := apiNumber("customer", body.Customer)
+		if err != nil {
+			return nil, err
+		}
+		return apiTransact(user, r, nil, "confirm_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.ConfirmSale(auth, index, saleTo)
+		}, index, saleTo)
+	case "cancel":
+		return apiTransact(user, r, nil, "cancel_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CancelSale(auth, index)
+		}, index)
+	}
+	return nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path)
+}
+
+// GET /rents, GET /rents/<id>, POST /rents {"estate_id", "days", "price"},
+// POST /rents/<id>/take|cancel|finish.
+func apiRents(user *worldskills.UserType, r *http.Request, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		if r.Method == "POST" {
+			var body struct {
+				EstateId string `json:"estate_id"`
+				Days string `json:"days"`
+				Price string `json:"price"`
+			}
+			if err := apiDecode(r, &body); err != nil {
+				return nil, err
+			}
+			estateId, err := apiNumber("estate_id", body.EstateId)
+			if err != nil {
+				return nil, err
+			}
+			days, err := apiNumber("days", body.Days)
+			if err != nil {
+				return nil, err
+			}
+			price, err := apiNumber("price", body.Price)
+			if err != nil {
+				return nil, err
+			}
+			return apiTransact(user, r, nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateRent(auth, estateId, days, price)
+			}, estateId, days, price)
+		}
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		list := []*worldskills.Rent{}
+		for _, rent := range worldskills.Index.Rents() {
+			if !rent.Finished && worldskills.MatchAddress(apiFilter(r), user, rent.OwnerAddress, rent.RenterAddress) {
+				list = append(list, rent)
+			}
+		}
+		return list, nil
+	}
+	index, err := apiIndex(user, "rent", path[0], worldskills.Backend.RentsNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		if err := apiMethod(r, "GET"); err != nil {
+			return nil, err
+		}
+		return apiRecord("rent", func() (interface{}, error) {
+			return worldskills.Backend.GetRents(user, index)
+		})
+	}
+	if err := apiMethod(r, "POST"); err != nil {
+		return nil, err
+	}
+	switch path[1] {
+	case "take":
+		value, err := worldskills.RentValue(user, index)
+		if err != nil {
+			return nil, apiErrorf(http.StatusBadGateway, "%w", err)
+		}
+		return apiTransact(user, r, value, "to_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.ToRent(auth, index)
+		}, index)
+	case "cancel":
+		return apiTransact(user, r, nil, "cancel_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CancelRent(auth, index)
+		}, index)
+	case "finish":
+		return apiTransact(user, r, nil, "finish_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.FinishRent(auth, index)
+		}, index)
+	}
+	return nil, apiErrorf(http.StatusNotFound, "unknown path %s", r.URL.Path)
+}
+
+// Filter of listings from ?address=, the same values as in MatchAddress,
+// own records by default.
+func apiFilter(r *http.Request) string {
+	if address := r.URL.Query().Get("address"); address != "" {
+		return address
+	}
+	return "my"
+}
@@ -0,0 +1,1102 @@
+package main
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"log"
+	"io/ioutil"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"strconv"
+	"context"
+	"net/http"
+	"math/big"
+	"html/template"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+const (
+	KEYSTORE_SIZE = 1 << 16
+)
+
+// Connect to chain and check that pages can be served.
+func setup() error {
+	worldskills.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	if err := worldskills.SetupChain(os.Args[1:]); err != nil {
+		return err
+	}
+	if _, err := os.Stat(worldskills.Config.TemplatesPath + "base.html"); err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("templates: %w", err))
+	}
+	if _, err := os.Stat(worldskills.Config.StaticPath); err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("static: %w", err))
+	}
+	var err error
+	worldskills.Index, err = worldskills.StartIndexer()
+	if err != nil {
+		return worldskills.WithExit(worldskills.EXIT_CONTRACT, fmt.Errorf("start indexer: %w", err))
+	}
+	return nil
+}
+
+func main() {
+	if err := setup(); err != nil {
+		worldskills.Fatal(err)
+	}
+
+	fmt.Println("Server is running on", worldskills.Config.Listen, "...")
+
+	if err := http.ListenAndServe(worldskills.Config.Listen, newServeMux()); err != nil {
+		worldskills.Fatal(worldskills.WithExit(worldskills.EXIT_CONFIG, fmt.Errorf("listen %s: %w", worldskills.Config.Listen, err)))
+	}
+}
+
+// Pages, static files and API. Handlers read the network holding
+// worldskills.NetworkLock, except while they wait for receipts,
+// profilePage takes it to switch the network.
+func newServeMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			worldskills.NetworkLock.RLock()
+			defer worldskills.NetworkLock.RUnlock()
+			handler(w, r)
+		})
+	}
+	mux.Handle("/static/", http.StripPrefix(
+		"/static/",
+		handleFileServer(http.Dir(worldskills.Config.StaticPath))),
+	)
+
+	handle("/", indexPage)
+	handle("/login", loginPage)
+	handle("/logout", logoutPage)
+	mux.HandleFunc("/profile", profilePage)
+	handle("/account", accountPage)
+
+	handle("/blockchain", blockchainPage)
+	handle("/blockchain/estates", blockchainEstatesPage)
+	handle("/blockchain/presents", blockchainPresentsPage)
+	handle("/blockchain/sales", blockchainSalesPage)
+	handle("/blockchain/rents", blockchainRentsPage)
+	handle("/blockchain/events", blockchainEventsPage)
+
+	handle("/blockchain/estates/", blockchainEstatesXPage)
+	handle("/blockchain/presents/", blockchainPresentsXPage)
+	handle("/blockchain/sales/", blockchainSalesXPage)
+	handle("/blockchain/rents/", blockchainRentsXPage)
+
+	handle("/blockchain/presents/do/", blockchainPresentsDoPage)
+	handle("/blockchain/sales/do/", blockchainSalesDoPage)
+	handle("/blockchain/rents/do/", blockchainRentsDoPage)
+
+	handle(API_PREFIX, apiServe)
+	return mux
+}
+
+func handleFileServer(fs http.FileSystem) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := fs.Open(r.URL.Path); os.IsNotExist(err) {
+			indexPage(w, r)
+			return
+		}
+		http.FileServer(fs).ServeHTTP(w, r)
+	})
+}
+
+// Templates of page with functions used by header of base.html.
+func parsePage(filenames ...string) (*template.Template, error) {
+	return template.New("base.html").Funcs(template.FuncMap{
+		"profile": func() string {
+			return worldskills.Config.Profile
+		},
+		"profiles": func() []string {
+			return worldskills.Config.ProfileNames()
+		},
+		"admin": func(user *worldskills.UserType) bool {
+			if user == nil {
+				return false
+			}
+			iamAdmin, err := worldskills.Backend.IamAdmin(user)
+			return err == nil && iamAdmin
+		},
+	}).ParseFiles(filenames...)
+}
+
+func indexPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"index.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	var data struct{
+		User *worldskills.UserType
+	}
+	data.User = user
+	t.Execute(w, data)
+}
+
+func loginPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"login.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	var data struct{
+		User *worldskills.UserType
+		Error string
+	}
+	if r.Method == "POST" {
+		r.ParseMultipartForm(KEYSTORE_SIZE)
+		if r.FormValue("private") != "" {
+			user, err = worldskills.LoadUser(r.FormValue("private"))
+		} else {
+			user, err = loadUserKeystore(r)
+		}
+		if err != nil {
+			data.Error = "Load Private Key Error: " + err.Error()
+		} else {
+			Sessions.Create(w, user)
+			http.Redirect(w, r, "/", 302)
+			return
+		}
+	}
+	data.User = user
+	t.Execute(w, data)
+}
+
+func loadUserKeystore(r *http.Request) (*worldskills.UserType, error) {
+	file, _, err := r.FormFile("keystore")
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	defer file.Close()
+	keyjson, err := ioutil.ReadAll(io.LimitReader(file, KEYSTORE_SIZE))
+	if err != nil {
+		return nil, fmt.Errorf("read keystore: %w", err)
+	}
+	priv, err := worldskills.DecryptKeystore(keyjson, r.FormValue("passphrase"))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore: %w", err)
+	}
+	return worldskills.LoadUser(hex.EncodeToString(crypto.FromECDSA(priv)))
+}
+
+func logoutPage(w http.ResponseWriter, r *http.Request) {
+	Sessions.Delete(w, r)
+	http.Redirect(w, r, "/", 302)
+}
+
+// Switch network of the whole server to profile from header.
+// Only admin of contract on the current network switches profile,
+// the switch changes network for every session.
+func profilePage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Redirect(w, r, "/", 302)
+		return
+	}
+	user := Sessions.User(r)
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	worldskills.NetworkLock.RLock()
+	iamAdmin, err := worldskills.Backend.IamAdmin(user)
+	worldskills.NetworkLock.RUnlock()
+	if err != nil {
+		http.Error(w, "Switch Profile Error: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if !iamAdmin {
+		http.Error(w, "Switch Profile Error: only admin of contract switches profile", http.StatusForbidden)
+		return
+	}
+	if err := worldskills.SwitchProfile(r.FormValue("profile")); err != nil {
+		http.Error(w, "Switch Profile Error: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	back := r.Header.Get("Referer")
+	if back == "" {
+		back = "/"
+	}
+	http.Redirect(w, r, back, 302)
+}
+
+func accountPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"account.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	var data struct{
+		User *worldskills.UserType
+		Address string
+		Balance string
+	}
+	data.User = user
+	if data.User != nil {
+		data.Address = user.AddressHex
+		balance, err := worldskills.ClientETH.BalanceAt(context.Background(), user.AddressEth, nil)
+		if err == nil {
+			data.Balance = balance.String()
+		}
+	} else {
+		http.Redirect(w, r, "/", 302)
+		return
+	}
+	t.Execute(w, data)
+}
+
+func blockchainPresentsDoPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"presentsDo.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.EstateStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/presents/do/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	estate, err := worldskills.Backend.GetEstates(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.EstatesToString(estate)
+	if r.Method == "POST" {
+		r.ParseForm()
+		message, asked := formTransact(w, r, user, nil, "create_present", "created", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CreatePresent(
+				auth, 
+				index, 
+				common.HexToAddress(r.FormValue("address")),
+			)
+		}, index, common.HexToAddress(r.FormValue("address")))
+		if asked {
+			return
+		}
+		data.Error = message
+	}
+	t.Execute(w, data)
+}
+
+func blockchainSalesDoPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"salesDo.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.EstateStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/sales/do/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	estate, err := worldskills.Backend.GetEstates(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.EstatesToString(estate)
+	if r.Method == "POST" {
+		r.ParseForm()
+		var price = new(big.Int)
+		price, ok = price.SetString(r.FormValue("price"), 10)
+		if !ok {
+			data.Error = "strconv error"
+			t.Execute(w, data)
+			return
+		}
+		message, asked := formTransact(w, r, user, nil, "create_sale", "created", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CreateSale(
+				auth, 
+				index, 
+				price,
+			)
+		}, index, price)
+		if asked {
+			return
+		}
+		data.Error = message
+	}
+	t.Execute(w, data)
+}
+
+func blockchainRentsDoPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"rentsDo.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.EstateStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/rents/do/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	estate, err := worldskills.Backend.GetEstates(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.EstatesToString(estate)
+	if r.Method == "POST" {
+		r.ParseForm()
+		var (
+			days = new(big.Int)
+			price = new(big.Int)
+		)
+		days, ok = days.SetString(r.FormValue("days"), 10)
+		if !ok {
+			data.Error = "strconv error 1"
+			t.Execute(w, data)
+			return
+		}
+		price, ok = price.SetString(r.FormValue("price"), 10)
+		if !ok {
+			data.Error = "strconv error 2"
+			t.Execute(w, data)
+			return
+		}
+		message, asked := formTransact(w, r, user, nil, "create_rent", "created", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CreateRent(
+				auth, 
+				index, 
+				days,
+				price,
+			)
+		}, index, days, price)
+		if asked {
+			return
+		}
+		data.Error = message
+	}
+	t.Execute(w, data)
+}
+
+func blockchainEstatesXPage(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/history") {
+		blockchainEstatesHistoryPage(w, r)
+		return
+	}
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"estatesX.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.EstateStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/estates/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	estate, err := worldskills.Backend.GetEstates(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.EstatesToString(estate)
+	t.Execute(w, data)
+}
+
+// Page /blockchain/estates/<id>/history.
+func blockchainEstatesHistoryPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"history.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Id string
+		History []*worldskills.HistoryType
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.TrimSuffix(strings.Replace(r.URL.Path, "/blockchain/estates/", "", 1), "/history")
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	data.Id = index.String()
+	data.History, err = worldskills.GetEstateHistory(index)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	t.Execute(w, data)
+}
+
+func blockchainPresentsXPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"presentsX.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.PresentStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/presents/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	present, err := worldskills.Backend.GetPresents(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.PresentsToString(present)
+	if r.Method == "POST" {
+		r.ParseForm()
+		if r.FormValue("cancel") != "" {
+			message, asked := formTransact(w, r, user, nil, "cancel_present", "cancel", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CancelPresent(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("confirm") != "" {
+			message, asked := formTransact(w, r, user, nil, "confirm_present", "confirm", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ConfirmPresent(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+	}
+	t.Execute(w, data)
+}
+
+func blockchainSalesXPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"salesX.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.SaleStr
+		IsCustomer bool
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/sales/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	sale, err := worldskills.Backend.GetSales(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.SalesToString(sale)
+	for _, customer := range data.Block.Customers {
+		if customer.Address == user.AddressHex && customer.Price.Sign() != 0 {
+			data.IsCustomer = true
+		}
+	}
+	if r.Method == "POST" {
+		r.ParseForm()
+		if r.FormValue("bid") != "" {
+			var value = new(big.Int)
+			value, ok = value.SetString(r.FormValue("value"), 10)
+			if !ok {
+				data.Error = "strconv error"
+				t.Execute(w, data)
+				return
+			}
+			message, asked := formTransact(w, r, user, value, "check_to_buy", "bid", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CheckToBuy(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("withdraw") != "" {
+			message, asked := formTransact(w, r, user, nil, "cancel_to_buy", "withdraw", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CancelToBuy(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("confirm") != "" {
+			var saleTo = new(big.Int)
+			saleTo, ok = saleTo.SetString(r.FormValue("customer"), 10)
+			if !ok || saleTo.Sign() < 0 {
+				data.Error = "strconv error"
+				t.Execute(w, data)
+				return
+			}
+			message, asked := formTransact(w, r, user, nil, "confirm_sale", "confirm", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ConfirmSale(
+					auth, 
+					index,
+					saleTo,
+				)
+			}, index, saleTo)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("cancel") != "" {
+			message, asked := formTransact(w, r, user, nil, "cancel_sale", "cancel", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CancelSale(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+	}
+	t.Execute(w, data)
+}
+
+func blockchainRentsXPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"rentsX.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		Block *worldskills.RentStr
+		Error string
+	}
+	data.User = user
+	var (
+		index = new(big.Int)
+		ok bool
+	)
+	num := strings.Replace(r.URL.Path, "/blockchain/rents/", "", 1)
+	index, ok = index.SetString(num, 10)
+	if !ok {
+		data.Error = "strconv error"
+		t.Execute(w, data)
+		return
+	}
+	rent, err := worldskills.Backend.GetRents(user, index)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	data.Block = worldskills.RentsToString(rent)
+	if r.Method == "POST" {
+		r.ParseForm()
+		if r.FormValue("rent") != "" {
+			value, err := worldskills.RentValue(user, index)
+			if err != nil {
+				data.Error = err.Error()
+				t.Execute(w, data)
+				return
+			}
+			message, asked := formTransact(w, r, user, value, "to_rent", "rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ToRent(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("cancel") != "" {
+			message, asked := formTransact(w, r, user, nil, "cancel_rent", "cancel", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CancelRent(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+		if r.FormValue("finish") != "" {
+			message, asked := formTransact(w, r, user, nil, "finish_rent", "finish", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.FinishRent(
+					auth, 
+					index,
+				)
+			}, index)
+			if asked {
+				return
+			}
+			data.Error = message
+		}
+	}
+	t.Execute(w, data)
+}
+
+// Wait tx and describe result of action for page.
+// Check method with Preflight and ask to confirm its gas and max cost on
+// a page of its own (asked is true), the confirmed form sends tx and waits
+// for receipt. Result or error is the message shown on page.
+func formTransact(w http.ResponseWriter, r *http.Request, user *worldskills.UserType, value *big.Int, method string, action string, send func(*bind.TransactOpts) (*types.Transaction, error), args ...interface{}) (message string, asked bool) {
+	auth, err := worldskills.ResetAuth(user)
+	if err != nil {
+		return err.Error(), false
+	}
+	auth.Value = value
+	if err := worldskills.Preflight(user, auth, method, args...); err != nil {
+		return err.Error(), false
+	}
+	if r.PostFormValue("confirmed") == "" {
+		return "", confirmPage(w, r, user, action, auth)
+	}
+	tx, err := worldskills.SendTx(auth, send)
+	if err != nil {
+		return err.Error(), false
+	}
+	return txResult(tx, action), false
+}
+
+// Form is posted again with the same values and confirmed set.
+func confirmPage(w http.ResponseWriter, r *http.Request, user *worldskills.UserType, action string, auth *bind.TransactOpts) bool {
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"confirm.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	type field struct{
+		Name string
+		Value string
+	}
+	var data struct{
+		User *worldskills.UserType
+		Action string
+		Path string
+		Gas string
+		Fields []field
+	}
+	data.User = user
+	data.Action = action
+	data.Path = r.URL.Path
+	data.Gas = worldskills.GasString(auth)
+	names := make([]string, 0, len(r.PostForm))
+	for name := range r.PostForm {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.PostForm[name] {
+			data.Fields = append(data.Fields, field{name, value})
+		}
+	}
+	t.Execute(w, data)
+	return true
+}
+
+// Receipt is waited for without NetworkLock, see WaitTxUnlocked.
+func txResult(tx *types.Transaction, action string) string {
+	receipt, err := worldskills.WaitTxUnlocked(tx)
+	if err != nil {
+		return err.Error()
+	}
+	cost := ", Max cost: " + tx.Cost().String() + " wei"
+	if !receipt.Success {
+		return "Failed " + action + " (" + tx.Hash().Hex() + "): " + receipt.String() + cost
+	}
+	return "Success " + action + " (" + tx.Hash().Hex() + "): " + receipt.String() + cost
+}
+
+func blockchainPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"blockchain.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		User *worldskills.UserType
+		IsAdmin bool
+		Error string
+	}
+	data.User = user
+	iamAdmin, err := worldskills.Backend.IamAdmin(user)
+	if err != nil {
+		data.Error = err.Error()
+		t.Execute(w, data)
+		return
+	}
+	if iamAdmin {
+		data.IsAdmin = true
+	}
+	if r.Method == "POST" {
+		r.ParseForm()
+		var (
+			squere = new(big.Int)
+			usefulSquere = new(big.Int)
+			ok bool
+		)
+		squere, ok = squere.SetString(r.FormValue("squere"), 10)
+		if !ok {
+			data.Error = "strconv error 1"
+			t.Execute(w, data)
+			return
+		}
+		usefulSquere, ok = usefulSquere.SetString(r.FormValue("usefulsquere"), 10)
+		if !ok {
+			data.Error = "strconv error 2"
+			t.Execute(w, data)
+			return
+		}
+		message, asked := formTransact(w, r, user, nil, "create_estate", "created", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return worldskills.Backend.CreateEstate(
+				auth, 
+				user.AddressEth, 
+				r.FormValue("info"),
+				squere,
+				usefulSquere,
+			)
+		}, user.AddressEth, r.FormValue("info"), squere, usefulSquere)
+		if asked {
+			return
+		}
+		data.Error = message
+	}
+	t.Execute(w, data)
+}
+
+func blockchainEstatesPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"estates.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		Error string
+		Blocks []uint64
+		Address string
+		User *worldskills.UserType
+	}
+	data.User = user
+	data.Address = user.AddressHex
+	if r.Method == "POST" {
+		data.Address = r.FormValue("address")
+	}
+	for index, block := range worldskills.Index.Estates() {
+		if !worldskills.MatchAddress(data.Address, user, block.Owner) {
+			continue
+		}
+		data.Blocks = append(data.Blocks, uint64(index))
+	}
+	t.Execute(w, data)
+}
+
+func blockchainPresentsPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"presents.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		Error string
+		Blocks []uint64
+		Address string
+		User *worldskills.UserType
+	}
+	data.User = user
+	data.Address = user.AddressHex
+	if r.Method == "POST" {
+		data.Address = r.FormValue("address")
+	}
+	for index, block := range worldskills.Index.Presents() {
+		if block.Finished || !worldskills.MatchAddress(data.Address, user, block.AddressFrom, block.AddressTo) {
+			continue
+		}
+		data.Blocks = append(data.Blocks, uint64(index))
+	}
+	t.Execute(w, data)
+}
+
+func blockchainSalesPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"sales.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		Error string
+		Blocks []uint64
+		Address string
+		User *worldskills.UserType
+	}
+	data.User = user
+	data.Address = user.AddressHex
+	if r.Method == "POST" {
+		data.Address = r.FormValue("address")
+	}
+	for index, block := range worldskills.Index.Sales() {
+		if block.Finished || !worldskills.MatchAddress(data.Address, user, append([]common.Address{block.Owner}, block.Customers...)...) {
+			continue
+		}
+		data.Blocks = append(data.Blocks, uint64(index))
+	}
+	t.Execute(w, data)
+}
+
+func blockchainRentsPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"rents.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		Error string
+		Blocks []uint64
+		Address string
+		User *worldskills.UserType
+	}
+	data.User = user
+	data.Address = user.AddressHex
+	if r.Method == "POST" {
+		data.Address = r.FormValue("address")
+	}
+	for index, block := range worldskills.Index.Rents() {
+		if block.Finished || !worldskills.MatchAddress(data.Address, user, block.OwnerAddress, block.RenterAddress) {
+			continue
+		}
+		data.Blocks = append(data.Blocks, uint64(index))
+	}
+	t.Execute(w, data)
+}
+
+func blockchainEventsPage(w http.ResponseWriter, r *http.Request) {
+	user := Sessions.User(r)
+	t, err := parsePage(
+		worldskills.Config.TemplatesPath+"base.html",
+		worldskills.Config.TemplatesPath+"events.html",
+	)
+	if err != nil {
+		panic("can't load hmtl files")
+	}
+	if user == nil {
+		http.Redirect(w, r, "/login", 302)
+		return
+	}
+	var data struct{
+		Error string
+		From uint64
+		Events []*worldskills.EventType
+		User *worldskills.UserType
+	}
+	data.User = user
+	if r.Method == "POST" {
+		data.From, err = strconv.ParseUint(r.FormValue("from"), 10, 64)
+		if err != nil {
+			data.Error = "strconv error"
+			t.Execute(w, data)
+			return
+		}
+	}
+	data.Events, err = worldskills.FilterEvents(data.From, nil)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	t.Execute(w, data)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"io"
+	"bytes"
+	"strings"
+	"testing"
+	"net/url"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"encoding/json"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+var backends = []string{"memory", "contract"}
+
+// Set up gclient like setup does, with templates and static files of repo.
+func newServer(t *testing.T, backend string, args ...string) (*httptest.Server, *chaintest.UsersType) {
+	t.Helper()
+	users := chaintest.Setup(t, backend, append([]string{"-templates:../../templates/", "-static:../../static/"}, args...)...)
+	index, err := worldskills.StartIndexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	worldskills.Index = index
+	server := httptest.NewServer(newServeMux())
+	t.Cleanup(server.Close)
+	return server, users
+}
+
+// Client with session of user, logged in through API.
+func login(t *testing.T, server *httptest.Server, user *worldskills.UserType) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+	status, body := apiCall(t, client, server, "POST", "login", map[string]string{"private": user.Purse})
+	if status != http.StatusOK {
+		t.Fatalf("login: %d %s", status, body)
+	}
+	return client
+}
+
+// Request path of API with JSON body, answer status and body.
+func apiCall(t *testing.T, client *http.Client, server *httptest.Server, method string, path string, body interface{}) (int, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, server.URL+API_PREFIX+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, data
+}
+
+// Write action of API, it must be mined with success.
+func apiSend(t *testing.T, client *http.Client, server *httptest.Server, path string, body interface{}) {
+	t.Helper()
+	status, data := apiCall(t, client, server, "POST", path, body)
+	if status != http.StatusOK {
+		t.Fatalf("POST %s: %d %s", path, status, data)
+	}
+	var result apiTxResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success {
+		t.Fatalf("POST %s: %s", path, data)
+	}
+}
+
+// Post form of page, answer body of page.
+func postForm(t *testing.T, client *http.Client, server *httptest.Server, path string, form url.Values) string {
+	t.Helper()
+	resp, err := client.PostForm(server.URL+path, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST %s: %d %s", path, resp.StatusCode, data)
+	}
+	return string(data)
+}
+
+// Estate is created with form of page and confirm page, then presented,
+// sold and rented through API. Each record is used right after it is
+// mined, before Index polls it.
+func TestServerLifecycles(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			server, users := newServer(t, backend)
+			admin := login(t, server, users.Admin)
+			first := login(t, server, users.First)
+			second := login(t, server, users.Second)
+
+			form := url.Values{"info": {"flat"}, "squere": {"100"}, "usefulsquere": {"80"}, "submit": {"Create"}}
+			page := postForm(t, admin, server, "/blockchain", form)
+			if !strings.Contains(page, "Confirm created?") || !strings.Contains(page, `name="confirmed" value="1"`) {
+				t.Fatalf("form is not asked to confirm:\n%s", page)
+			}
+			if number, _ := worldskills.Backend.EstatesNumber(users.Admin); number.Sign() != 0 {
+				t.Fatal("estate is created before confirm")
+			}
+			form.Set("confirmed", "1")
+			if page := postForm(t, admin, server, "/blockchain", form); !strings.Contains(page, "Success created") {
+				t.Fatalf("confirmed form is not sent:\n%s", page)
+			}
+
+			status, data := apiCall(t, admin, server, "POST", "presents?dry_run=1", map[string]string{"estate_id": "0", "address": users.First.AddressHex})
+			var estimate apiEstimateResult
+			if status != http.StatusOK || json.Unmarshal(data, &estimate) != nil || estimate.Gas == 0 || estimate.MaxCost == nil {
+				t.Fatalf("dry run: %d %s", status, data)
+			}
+			apiSend(t, admin, server, "presents", map[string]string{"estate_id": "0", "address": users.First.AddressHex})
+			apiSend(t, first, server, "presents/0/confirm", nil)
+
+			apiSend(t, first, server, "sales", map[string]string{"estate_id": "0", "price": "1000"})
+			if status, data := apiCall(t, second, server, "POST", "sales/0/bid", map[string]string{"value": "999"}); status != http.StatusUnprocessableEntity {
+				t.Fatalf("low bid: %d %s, want 422", status, data)
+			}
+			apiSend(t, second, server, "sales/0/bid", map[string]string{"value": "1000"})
+			if status, data := apiCall(t, first, server, "POST", "sales/0/confirm", map[string]string{"customer": "-1"}); status != http.StatusBadRequest {
+				t.Fatalf("negative customer: %d %s, want 400", status, data)
+			}
+			apiSend(t, first, server, "sales/0/confirm", map[string]string{"customer": "0"})
+
+			apiSend(t, second, server, "rents", map[string]string{"estate_id": "0", "days": "0", "price": "500"})
+			apiSend(t, first, server, "rents/0/take", nil)
+			chaintest.Mine(t)
+			apiSend(t, second, server, "rents/0/finish", nil)
+
+			status, data = apiCall(t, second, server, "GET", "estates/0", nil)
+			var estate worldskills.Estate
+			if status != http.StatusOK || json.Unmarshal(data, &estate) != nil {
+				t.Fatalf("get estate: %d %s", status, data)
+			}
+			if estate.Owner != users.Second.AddressEth || estate.RentStatus {
+				t.Fatalf("estate %s, want of second user without rent", data)
+			}
+			status, data = apiCall(t, second, server, "GET", "estates/0/history", nil)
+			var history []json.RawMessage
+			if status != http.StatusOK || json.Unmarshal(data, &history) != nil || len(history) != 5 {
+				t.Fatalf("history: %d %s, want 5 steps", status, data)
+			}
+		})
+	}
+}
+
+// API and pages need session.
+func TestServerLoginRequired(t *testing.T) {
+	server, _ := newServer(t, "memory")
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	if status, data := apiCall(t, client, server, "GET", "estates", nil); status != http.StatusUnauthorized {
+		t.Fatalf("estates without login: %d %s, want 401", status, data)
+	}
+	resp, err := client.PostForm(server.URL+"/blockchain", url.Values{"info": {"flat"}, "squere": {"100"}, "usefulsquere": {"80"}, "confirmed": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/login" {
+		t.Fatalf("form without login: %d to %q, want redirect to /login", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+// Only admin switches profile, requests served meanwhile see either
+// network but never a half switched one.
+func TestServerSwitchProfile(t *testing.T) {
+	users, addresses := chaintest.SetupProfiles(t, []string{"a", "b"}, "-templates:../../templates/", "-static:../../static/")
+	index, err := worldskills.StartIndexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	worldskills.Index = index
+	server := httptest.NewServer(newServeMux())
+	t.Cleanup(server.Close)
+	admin := login(t, server, users.Admin)
+	first := login(t, server, users.First)
+
+	for _, test := range []struct{ client *http.Client; selector bool }{{admin, true}, {first, false}} {
+		resp, err := test.client.Get(server.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), `action="/profile"`) != test.selector {
+			t.Fatalf("header shows profile selector: %v, want %v", !test.selector, test.selector)
+		}
+	}
+
+	resp, err := first.PostForm(server.URL+"/profile", url.Values{"profile": {"b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || worldskills.Config.Profile != "a" {
+		t.Fatalf("switch by user: %d to profile %s, want 403", resp.StatusCode, worldskills.Config.Profile)
+	}
+
+	var (
+		done = make(chan struct{})
+		started = make(chan struct{}, 4)
+		readers = make(chan error)
+	)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for n := 0; ; n++ {
+				if n == 1 {
+					started <- struct{}{}
+				}
+				select {
+				case <-done:
+					readers <- nil
+					return
+				default:
+				}
+				resp, err := first.Get(server.URL + API_PREFIX + "account")
+				if err != nil {
+					readers <- err
+					return
+				}
+				resp.Body.Close()
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-started
+	}
+	resp, err = admin.PostForm(server.URL+"/profile", url.Values{"profile": {"b"}})
+	close(done)
+	for i := 0; i < 4; i++ {
+		if err := <-readers; err != nil {
+			t.Error(err)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || worldskills.Config.Profile != "b" || worldskills.ContractAddr != addresses["b"] {
+		t.Fatalf("switch by admin: %d to contract %s of profile %s, want %s of b", resp.StatusCode, worldskills.ContractAddr.Hex(), worldskills.Config.Profile, addresses["b"].Hex())
+	}
+}
+
+// Call refused by contract is a mistake of client, failed gas estimate
+// is not. The first user to log in is not admin of memory backend.
+func TestServerPreflightStatus(t *testing.T) {
+	server, users := newServer(t, "memory", "-gascaps:create_present=1000")
+	first := login(t, server, users.First)
+	admin := login(t, server, users.Admin)
+	estate := map[string]string{"info": "flat", "squere": "100", "useful_squere": "80"}
+	if status, data := apiCall(t, first, server, "POST", "estates", estate); status != http.StatusUnprocessableEntity {
+		t.Fatalf("estate of user: %d %s, want 422", status, data)
+	}
+	apiSend(t, admin, server, "estates", estate)
+	if status, data := apiCall(t, admin, server, "POST", "presents", map[string]string{"estate_id": "0", "address": users.First.AddressHex}); status != http.StatusBadGateway {
+		t.Fatalf("present above gas cap: %d %s, want 502", status, data)
+	}
+}
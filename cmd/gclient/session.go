@@ -0,0 +1,114 @@
+package main
+
+import (
+	"sync"
+	"time"
+	"net/http"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+const (
+	SESSION_COOKIE = "session"
+	SESSION_TTL    = 30 * time.Minute
+	SESSION_CLEAN  = time.Minute
+)
+
+type SessionType struct {
+	User *worldskills.UserType
+	Expires time.Time
+}
+
+type SessionStore struct {
+	mutex sync.Mutex
+	sessions map[string]*SessionType
+}
+
+var (
+	Sessions = newSessionStore()
+)
+
+func newSessionStore() *SessionStore {
+	store := &SessionStore{
+		sessions: make(map[string]*SessionType),
+	}
+	go store.cleaner()
+	return store
+}
+
+// Create starts a new session for user and sets its cookie.
+func (store *SessionStore) Create(w http.ResponseWriter, user *worldskills.UserType) {
+	id := sessionID()
+	store.mutex.Lock()
+	store.sessions[id] = &SessionType{
+		User: user,
+		Expires: time.Now().Add(SESSION_TTL),
+	}
+	store.mutex.Unlock()
+	http.SetCookie(w, &http.Cookie{
+		Name: SESSION_COOKIE,
+		Value: id,
+		Path: "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// User returns the logged in user of request or nil.
+// Every successful lookup extends the session.
+func (store *SessionStore) User(r *http.Request) *worldskills.UserType {
+	cookie, err := r.Cookie(SESSION_COOKIE)
+	if err != nil {
+		return nil
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	session, ok := store.sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(session.Expires) {
+		delete(store.sessions, cookie.Value)
+		return nil
+	}
+	session.Expires = time.Now().Add(SESSION_TTL)
+	return session.User
+}
+
+// Delete ends the session of request and clears its cookie.
+func (store *SessionStore) Delete(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(SESSION_COOKIE)
+	if err == nil {
+		store.mutex.Lock()
+		delete(store.sessions, cookie.Value)
+		store.mutex.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name: SESSION_COOKIE,
+		Value: "",
+		Path: "/",
+		MaxAge: -1,
+	})
+}
+
+func (store *SessionStore) cleaner() {
+	for range time.Tick(SESSION_CLEAN) {
+		now := time.Now()
+		store.mutex.Lock()
+		for id, session := range store.sessions {
+			if now.After(session.Expires) {
+				delete(store.sessions, id)
+			}
+		}
+		store.mutex.Unlock()
+	}
+}
+
+func sessionID() string {
+	var buf [32]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic("failed: read random")
+	}
+	return hex.EncodeToString(buf[:])
+}
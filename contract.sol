@@ -49,6 +49,20 @@ contract WorldSkills {
     address admin = msg.sender;
     address payable default_address = 0x0000000000000000000000000000000000000000;
     
+    event EstateCreated(uint indexed estate_id, address indexed owner);
+    event PresentCreated(uint indexed present_id, uint indexed estate_id, address from, address to);
+    event PresentCancelled(uint indexed present_id, uint indexed estate_id);
+    event PresentConfirmed(uint indexed present_id, uint indexed estate_id, address from, address to);
+    event SaleCreated(uint indexed sale_id, uint indexed estate_id, address owner, uint price);
+    event BidPlaced(uint indexed sale_id, uint indexed estate_id, address customer, uint price);
+    event BidWithdrawn(uint indexed sale_id, uint indexed estate_id, address customer);
+    event SaleCancelled(uint indexed sale_id, uint indexed estate_id);
+    event SaleConfirmed(uint indexed sale_id, uint indexed estate_id, address from, address to, uint price);
+    event RentCreated(uint indexed rent_id, uint indexed estate_id, address owner, uint time, uint money);
+    event RentTaken(uint indexed rent_id, uint indexed estate_id, address renter, uint money);
+    event RentCancelled(uint indexed rent_id, uint indexed estate_id);
+    event RentFinished(uint indexed rent_id, uint indexed estate_id, address renter);
+    
     function iam_admin() public view returns(bool) {
         return msg.sender == admin;
     }
@@ -108,11 +122,13 @@ contract WorldSkills {
 
     function create_estate(address owner, string memory info, uint squere, uint useful_squere) public is_admin{
         estates.push(Estate(estates.length, owner, info, squere, useful_squere, 0x0000000000000000000000000000000000000000, false, false, false));
+        emit EstateCreated(estates.length - 1, owner);
     }
     
     function create_present(uint estate_id, address address_to) public status_OK(estate_id) is_owner(estate_id) {
         presents.push(Present(estate_id, msg.sender, address_to, false));
         estates[estate_id].present_status = true;
+        emit PresentCreated(presents.length - 1, estate_id, msg.sender, address_to);
     } 
     
     function cancel_present(uint present_number) payable public {
@@ -120,7 +136,7 @@ contract WorldSkills {
         require(presents[present_number].finished == false);
         estates[presents[present_number].estate_id].present_status = false;
         presents[present_number].finished = true;
-        
+        emit PresentCancelled(present_number, presents[present_number].estate_id);
     }
     
     function confirm_present(uint present_number) payable public {
@@ -129,7 +145,7 @@ contract WorldSkills {
         estates[presents[present_number].estate_id].owner = presents[present_number].address_to;
         estates[presents[present_number].estate_id].present_status = false;
         presents[present_number].finished = true;
-        
+        emit PresentConfirmed(present_number, presents[present_number].estate_id, presents[present_number].address_from, msg.sender);
     }
     
     function create_sale(uint estate_id, uint price) public status_OK(estate_id) is_owner(estate_id){
@@ -137,6 +153,7 @@ contract WorldSkills {
        uint[] memory prices;
        sales.push(Sale(estate_id, msg.sender, price, customers, prices, false));
        estates[estate_id].sale_status = true;
+       emit SaleCreated(sales.length - 1, estate_id, msg.sender, price);
     }
     
     function cancel_sale(uint sale_number) public {
@@ -147,7 +164,7 @@ contract WorldSkills {
         }
         estates[sales[sale_number].estate_id].sale_status = false;
         sales[sale_number].finished = true;
-        
+        emit SaleCancelled(sale_number, sales[sale_number].estate_id);
     }
     
     function check_to_buy(uint sale_number) public payable {
@@ -164,24 +181,32 @@ contract WorldSkills {
         require(status == 0);
         sales[sale_number].customers.push(msg.sender);
         sales[sale_number].prices.push(msg.value);
+        emit BidPlaced(sale_number, sales[sale_number].estate_id, msg.sender, msg.value);
     }
     
     function cancel_to_buy(uint sale_number) public payable {
         require(sales[sale_number].finished == false);
+        bool withdrawn = false;
         for (uint i=0; i<sales[sale_number].customers.length; i++){
-            if (sales[sale_number].customers[i] == msg.sender){
+            if (sales[sale_number].customers[i] == msg.sender && sales[sale_number].prices[i] != 0){
                 msg.sender.transfer(sales[sale_number].prices[i]);
                 delete sales[sale_number].prices[i];
+                withdrawn = true;
             }
         }
+        if (withdrawn) {
+            emit BidWithdrawn(sale_number, sales[sale_number].estate_id, msg.sender);
+        }
     }
     
     function confirm_sale(uint sale_number, uint sale_to) public payable {
         require(msg.sender == sales[sale_number].owner);
         require(sales[sale_number].prices[sale_to] != 0);
         require(sales[sale_number].finished == false);
-        estates[sales[sale_number].estate_id].owner = sales[sale_number].customers[sale_to];
-        msg.sender.transfer(sales[sale_number].prices[sale_to]);
+        uint price = sales[sale_number].prices[sale_to];
+        address payable customer = sales[sale_number].customers[sale_to];
+        estates[sales[sale_number].estate_id].owner = customer;
+        msg.sender.transfer(price);
         for (uint i=0; i<sales[sale_number].customers.length; i++){
             if (i != sale_to) {
                 sales[sale_number].customers[i].transfer(sales[sale_number].prices[i]);
@@ -192,11 +217,13 @@ contract WorldSkills {
         }
         estates[sales[sale_number].estate_id].sale_status = false;
         sales[sale_number].finished = true;
+        emit SaleConfirmed(sale_number, sales[sale_number].estate_id, msg.sender, customer, price);
     }
 
     function create_rent(uint estate_id, uint time, uint money) public is_owner(estate_id) status_OK(estate_id){
         rents.push(Rent(estate_id, msg.sender, default_address, time, money, 0, false));
         estates[estate_id].rent_status=true;
+        emit RentCreated(rents.length - 1, estate_id, msg.sender, time, money);
     }
     
     function to_rent(uint rent_id) public payable{
@@ -208,6 +235,7 @@ contract WorldSkills {
         estates[rents[rent_id].estate_id].renter_address = msg.sender;
         rents[rent_id].deadline = now + rents[rent_id].time*86400;
         rents[rent_id].owner_address.transfer(rents[rent_id].money);
+        emit RentTaken(rent_id, rents[rent_id].estate_id, msg.sender, msg.value);
     }
     
     function cancel_rent(uint rent_id) public {
@@ -216,6 +244,7 @@ contract WorldSkills {
         require(rents[rent_id].renter_address == default_address);
         estates[rents[rent_id].estate_id].rent_status=false;
         rents[rent_id].finished = true;
+        emit RentCancelled(rent_id, rents[rent_id].estate_id);
     }
     
     function finish_rent(uint rent_id) public is_owner(rents[rent_id].estate_id) { 
@@ -224,5 +253,6 @@ contract WorldSkills {
         estates[rents[rent_id].estate_id].renter_address = default_address;
         estates[rents[rent_id].estate_id].rent_status=false;
         rents[rent_id].finished = true;
+        emit RentFinished(rent_id, rents[rent_id].estate_id, rents[rent_id].renter_address);
     }
 }
@@ -0,0 +1,347 @@
+// Package chaintest runs worldskills on a simulated chain with deployed
+// contract, or on the memory backend, for tests of worldskills and commands.
+// Setup replaces the globals of worldskills, so tests using it can not run
+// in parallel.
+package chaintest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"testing"
+	"context"
+	"math/big"
+	"io/ioutil"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	contract "github.com/number571/contract-interfaces/contracts"
+	"github.com/number571/contract-interfaces/worldskills"
+)
+
+const (
+	// Balance of every user on simulated chain, 100 ether.
+	BALANCE = "100000000000000000000"
+	GAS_LIMIT = 30000000
+	// Chain id of simulated chain, it is fixed by go-ethereum.
+	CHAIN_ID = 1337
+)
+
+// Admin deploys contract, First and Second are other funded accounts.
+type UsersType struct {
+	Admin *worldskills.UserType
+	First *worldskills.UserType
+	Second *worldskills.UserType
+}
+
+// Simulated node which mines every sent transaction at once into its own
+// block, like Ganache. Chains get their own network ids, so deployments
+// to several of them are kept in one deployments file.
+type ChainType struct {
+	*backends.SimulatedBackend
+	URL string
+	NetworkId *big.Int
+	closeOnce sync.Once
+	closed chan struct{}
+	mutex sync.Mutex
+	holding bool
+	held []*types.Transaction
+}
+
+var chains = make(map[string]*ChainType)
+
+func init() {
+	dial := worldskills.DialETH
+	worldskills.DialETH = func(address string) (worldskills.ChainBackend, error) {
+		if chain, ok := chains[address]; ok {
+			return chain, nil
+		}
+		return dial(address)
+	}
+}
+
+// Three users with new keys.
+func NewUsers(t testing.TB) *UsersType {
+	t.Helper()
+	var users [3]*worldskills.UserType
+	for i := range users {
+		priv, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		users[i], err = worldskills.LoadUser(hex.EncodeToString(crypto.FromECDSA(priv)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &UsersType{Admin: users[0], First: users[1], Second: users[2]}
+}
+
+// New chain with users funded, reached by worldskills.DialETH at chain.URL.
+// Blocks are 10 seconds apart and node refuses blocks from future, so block
+// time starts a day before current time.
+func NewChain(t testing.TB, users *UsersType) *ChainType {
+	t.Helper()
+	balance, _ := new(big.Int).SetString(BALANCE, 10)
+	alloc := core.GenesisAlloc{}
+	for _, user := range []*worldskills.UserType{users.Admin, users.First, users.Second} {
+		alloc[user.AddressEth] = core.GenesisAccount{Balance: balance}
+	}
+	chain := &ChainType{
+		SimulatedBackend: backends.NewSimulatedBackend(alloc, GAS_LIMIT),
+		URL: fmt.Sprintf("simulated://%d", len(chains)),
+		NetworkId: big.NewInt(int64(CHAIN_ID + len(chains))),
+		closed: make(chan struct{}),
+	}
+	if err := chain.AdjustTime(time.Duration(time.Now().Add(-24 * time.Hour).Unix()) * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	chain.Commit()
+	chains[chain.URL] = chain
+	t.Cleanup(func() {
+		delete(chains, chain.URL)
+		chain.Close()
+	})
+	return chain
+}
+
+// Close node like ethclient.Client, by worldskills when network is
+// switched or at the end of test, whichever is the first.
+func (chain *ChainType) Close() {
+	chain.closeOnce.Do(func() {
+		chain.SimulatedBackend.Close()
+		close(chain.closed)
+	})
+}
+
+// Closed is done when node is closed.
+func (chain *ChainType) Closed() <-chan struct{} {
+	return chain.closed
+}
+
+// Keep sent transactions pending until Release, like txpool of a node.
+// Pending transaction is replaced by one with the same sender and nonce
+// if its fees are at least 10% higher, the rule of go-ethereum.
+func (chain *ChainType) Hold() {
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+	chain.holding = true
+}
+
+// Mine held transactions in one block and send the next ones at once.
+func (chain *ChainType) Release(t testing.TB) {
+	t.Helper()
+	chain.mutex.Lock()
+	held := chain.held
+	chain.holding, chain.held = false, nil
+	chain.mutex.Unlock()
+	for _, tx := range held {
+		if err := chain.SimulatedBackend.SendTransaction(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain.Commit()
+}
+
+func (chain *ChainType) hold(tx *types.Transaction) error {
+	signer := types.LatestSignerForChainID(big.NewInt(CHAIN_ID))
+	sender, err := types.Sender(signer, tx)
+	if err != nil {
+		return err
+	}
+	for i, pending := range chain.held {
+		if from, _ := types.Sender(signer, pending); from != sender || pending.Nonce() != tx.Nonce() {
+			continue
+		}
+		bumped := func(fee *big.Int) *big.Int {
+			return new(big.Int).Div(new(big.Int).Mul(fee, big.NewInt(110)), big.NewInt(100))
+		}
+		if tx.GasFeeCap().Cmp(bumped(pending.GasFeeCap())) < 0 || tx.GasTipCap().Cmp(bumped(pending.GasTipCap())) < 0 {
+			return fmt.Errorf("replacement transaction underpriced")
+		}
+		chain.held[i] = tx
+		return nil
+	}
+	chain.held = append(chain.held, tx)
+	return nil
+}
+
+func (chain *ChainType) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	chain.mutex.Lock()
+	for _, tx := range chain.held {
+		if tx.Hash() == hash {
+			chain.mutex.Unlock()
+			return tx, true, nil
+		}
+	}
+	chain.mutex.Unlock()
+	return chain.SimulatedBackend.TransactionByHash(ctx, hash)
+}
+
+func (chain *ChainType) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	chain.mutex.Lock()
+	if chain.holding {
+		defer chain.mutex.Unlock()
+		return chain.hold(tx)
+	}
+	chain.mutex.Unlock()
+	if err := chain.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	chain.Commit()
+	return nil
+}
+
+func (chain *ChainType) BlockNumber(ctx context.Context) (uint64, error) {
+	return chain.Blockchain().CurrentBlock().NumberU64(), nil
+}
+
+func (chain *ChainType) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(CHAIN_ID), nil
+}
+
+func (chain *ChainType) NetworkID(ctx context.Context) (*big.Int, error) {
+	return chain.NetworkId, nil
+}
+
+// Mine empty block on simulated chain of worldskills.ClientETH, so calls
+// see block time 10 seconds later, e.g. after deadline of rent for 0 days.
+// Memory backend needs none, its calls run in the next second.
+func Mine(t testing.TB) {
+	t.Helper()
+	if chain, ok := worldskills.ClientETH.(*ChainType); ok {
+		chain.Commit()
+	}
+}
+
+// Send nothing from user to itself.
+func (chain *ChainType) transfer(t testing.TB, user *worldskills.UserType, nonce uint64) {
+	t.Helper()
+	tx := types.NewTransaction(nonce, user.AddressEth, big.NewInt(0), 21000, big.NewInt(params.GWei), nil)
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(CHAIN_ID)), user.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chain.SendTransaction(context.Background(), signed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Deploy contract from admin and record it in deployments file.
+func (chain *ChainType) Deploy(t testing.TB, admin *worldskills.UserType, deployments string) common.Address {
+	t.Helper()
+	auth, err := bind.NewKeyedTransactorWithChainID(admin.PrivateKey, big.NewInt(CHAIN_ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, tx, _, err := contract.DeployContract(auth, chain)
+	if err != nil {
+		t.Fatalf("deploy contract: %v", err)
+	}
+	receipt, err := chain.TransactionReceipt(context.Background(), tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("deployment tx %s reverted", tx.Hash().Hex())
+	}
+	header, err := chain.HeaderByNumber(context.Background(), receipt.BlockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = worldskills.SaveDeployment(deployments, &worldskills.DeploymentType{
+		NetworkId: chain.NetworkId,
+		Address: address,
+		TxHash: tx.Hash(),
+		Block: receipt.BlockNumber.Uint64(),
+		Deployer: admin.AddressEth,
+		Timestamp: time.Unix(int64(header.Time), 0).UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+// Set up worldskills with SetupChain on backend "memory", or on "contract"
+// deployed to new simulated chain. Index is stopped at the end of test.
+func Setup(t testing.TB, backend string, args ...string) *UsersType {
+	t.Helper()
+	users := NewUsers(t)
+	dir := t.TempDir()
+	switch backend {
+	case "memory":
+		args = append(args, "-backend:memory", "-memoryadmin:"+users.Admin.AddressHex)
+	case "contract":
+		chain := NewChain(t, users)
+		chain.Deploy(t, users.Admin, filepath.Join(dir, "deployments.json"))
+		args = append(args, "-rpc:"+chain.URL)
+	default:
+		t.Fatalf("unknown backend %q", backend)
+	}
+	setupChain(t, dir, args)
+	return users
+}
+
+// Set up worldskills with config of profiles, each one on its own
+// simulated chain with contract deployed by admin. The first profile
+// is selected. Addresses of contracts are returned by profile.
+func SetupProfiles(t testing.TB, profiles []string, args ...string) (*UsersType, map[string]common.Address) {
+	t.Helper()
+	var (
+		users = NewUsers(t)
+		dir = t.TempDir()
+		config = struct{
+			Profiles map[string]*worldskills.ProfileType `json:"profiles"`
+		}{make(map[string]*worldskills.ProfileType)}
+		addresses = make(map[string]common.Address)
+	)
+	for i, name := range profiles {
+		chain := NewChain(t, users)
+		// Chains have the same chain id, so the same deployment tx would
+		// be recorded once. Nonce of admin differs on each chain.
+		for nonce := 0; nonce < i; nonce++ {
+			chain.transfer(t, users.Admin, uint64(nonce))
+		}
+		addresses[name] = chain.Deploy(t, users.Admin, filepath.Join(dir, "deployments.json"))
+		config.Profiles[name] = &worldskills.ProfileType{RPC: chain.URL}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.json"), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	setupChain(t, dir, append(args, "-config:"+filepath.Join(dir, "config.json"), "-profile:"+profiles[0]))
+	return users, addresses
+}
+
+// Files of config are kept in dir, args override them.
+func setupChain(t testing.TB, dir string, args []string) {
+	t.Helper()
+	args = append([]string{
+		"-pollinterval:100ms",
+		"-txtimeout:10s",
+		"-deployments:" + filepath.Join(dir, "deployments.json"),
+		"-contractfile:" + filepath.Join(dir, "contract.address"),
+	}, args...)
+	worldskills.Memory = nil
+	worldskills.Index = nil
+	t.Cleanup(func() {
+		if worldskills.Index != nil {
+			worldskills.Index.Stop()
+			worldskills.Index = nil
+		}
+	})
+	if err := worldskills.SetupChain(args); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,330 @@
+package worldskills
+
+import (
+	"os"
+	"time"
+	"fmt"
+	"strings"
+	"strconv"
+	"math/big"
+	"io/ioutil"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	DEFAULT_RPC           = "http://127.0.0.1:7545"
+	DEFAULT_DEPLOYMENTS   = "deployments.json"
+	DEFAULT_CONTRACT_FILE = "contract.address"
+	DEFAULT_LISTEN        = ":8080"
+	DEFAULT_STATIC_PATH   = "static/"
+	DEFAULT_TMPL_PATH     = "templates/"
+	DEFAULT_TX_TIMEOUT    = "60s"
+	DEFAULT_POLL_INTERVAL = "5s"
+	DEFAULT_GAS_LIMIT     = "estimate"
+	DEFAULT_GAS_MULT      = "1.2"
+	DEFAULT_FEES          = "auto"
+	DEFAULT_BACKEND       = "contract"
+)
+
+type ConfigType struct {
+	RPC string `json:"rpc"`
+	Contract string `json:"contract"`
+	Deployments string `json:"deployments_file"`
+	ContractFile string `json:"contract_file"`
+	Listen string `json:"listen"`
+	StaticPath string `json:"static_path"`
+	TemplatesPath string `json:"templates_path"`
+	TxTimeout string `json:"tx_timeout"`
+	PollInterval string `json:"poll_interval"`
+	CacheFile string `json:"cache_file"`
+	GasLimit string `json:"gas_limit"`
+	GasMultiplier string `json:"gas_multiplier"`
+	GasCaps string `json:"gas_caps"`
+	Fees string `json:"fees"`
+	MaxFee string `json:"max_fee"`
+	PriorityFee string `json:"priority_fee"`
+	Backend string `json:"backend"`
+	MemoryAdmin string `json:"memory_admin"`
+	ChainId string `json:"chain_id"`
+	Account string `json:"account"`
+	Profile string `json:"profile"`
+	Profiles map[string]*ProfileType `json:"profiles"`
+}
+
+// Settings are applied in order: defaults, JSON file (-config:<path> or WS_CONFIG),
+// selected profile of JSON file, environment variables WS_*,
+// command line flags -<name>:<value> (or --<name>=<value>).
+func LoadConfig(args []string) (*ConfigType, error) {
+	cfg := &ConfigType{
+		RPC: DEFAULT_RPC,
+		Deployments: DEFAULT_DEPLOYMENTS,
+		ContractFile: DEFAULT_CONTRACT_FILE,
+		Listen: DEFAULT_LISTEN,
+		StaticPath: DEFAULT_STATIC_PATH,
+		TemplatesPath: DEFAULT_TMPL_PATH,
+		TxTimeout: DEFAULT_TX_TIMEOUT,
+		PollInterval: DEFAULT_POLL_INTERVAL,
+		GasLimit: DEFAULT_GAS_LIMIT,
+		GasMultiplier: DEFAULT_GAS_MULT,
+		Fees: DEFAULT_FEES,
+		Backend: DEFAULT_BACKEND,
+	}
+	configFile := os.Getenv("WS_CONFIG")
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-config:"):
+			configFile = strings.Replace(arg, "-config:", "", 1)
+		case strings.HasPrefix(arg, "--config="):
+			configFile = strings.Replace(arg, "--config=", "", 1)
+		}
+	}
+	if configFile != "" {
+		data, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	}
+	var (
+		fields = map[string]*string{
+			"rpc": &cfg.RPC,
+			"contract": &cfg.Contract,
+			"deployments": &cfg.Deployments,
+			"contractfile": &cfg.ContractFile,
+			"listen": &cfg.Listen,
+			"static": &cfg.StaticPath,
+			"templates": &cfg.TemplatesPath,
+			"txtimeout": &cfg.TxTimeout,
+			"pollinterval": &cfg.PollInterval,
+			"cache": &cfg.CacheFile,
+			"gaslimit": &cfg.GasLimit,
+			"gasmultiplier": &cfg.GasMultiplier,
+			"gascaps": &cfg.GasCaps,
+			"fees": &cfg.Fees,
+			"maxfee": &cfg.MaxFee,
+			"priorityfee": &cfg.PriorityFee,
+			"backend": &cfg.Backend,
+			"memoryadmin": &cfg.MemoryAdmin,
+			"chainid": &cfg.ChainId,
+			"account": &cfg.Account,
+			"profile": &cfg.Profile,
+		}
+		envs = map[string]string{
+			"rpc": "WS_RPC",
+			"contract": "WS_CONTRACT",
+			"deployments": "WS_DEPLOYMENTS",
+			"contractfile": "WS_CONTRACT_FILE",
+			"listen": "WS_LISTEN",
+			"static": "WS_STATIC",
+			"templates": "WS_TEMPLATES",
+			"txtimeout": "WS_TX_TIMEOUT",
+			"pollinterval": "WS_POLL_INTERVAL",
+			"cache": "WS_CACHE",
+			"gaslimit": "WS_GAS_LIMIT",
+			"gasmultiplier": "WS_GAS_MULTIPLIER",
+			"gascaps": "WS_GAS_CAPS",
+			"fees": "WS_FEES",
+			"maxfee": "WS_MAX_FEE",
+			"priorityfee": "WS_PRIORITY_FEE",
+			"backend": "WS_BACKEND",
+			"memoryadmin": "WS_MEMORY_ADMIN",
+			"chainid": "WS_CHAIN_ID",
+			"account": "WS_ACCOUNT",
+			"profile": "WS_PROFILE",
+		}
+	)
+	// Profile is chosen first, so environment and flags override its settings.
+	if value, ok := os.LookupEnv(envs["profile"]); ok {
+		cfg.Profile = value
+	}
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "-profile:"):
+			cfg.Profile = strings.Replace(arg, "-profile:", "", 1)
+		case strings.HasPrefix(arg, "--profile="):
+			cfg.Profile = strings.Replace(arg, "--profile=", "", 1)
+		}
+	}
+	if err := cfg.applyProfile(); err != nil {
+		return nil, err
+	}
+	for name, env := range envs {
+		if value, ok := os.LookupEnv(env); ok {
+			*fields[name] = value
+		}
+	}
+	for _, arg := range args {
+		for name, field := range fields {
+			switch {
+			case strings.HasPrefix(arg, "-"+name+":"):
+				*field = strings.Replace(arg, "-"+name+":", "", 1)
+			case strings.HasPrefix(arg, "--"+name+"="):
+				*field = strings.Replace(arg, "--"+name+"=", "", 1)
+			}
+		}
+	}
+	if _, err := time.ParseDuration(cfg.TxTimeout); err != nil {
+		return nil, fmt.Errorf("tx timeout: %w", err)
+	}
+	if interval, err := time.ParseDuration(cfg.PollInterval); err != nil || interval <= 0 {
+		return nil, fmt.Errorf("poll interval %q is invalid", cfg.PollInterval)
+	}
+	if err := cfg.checkGas(); err != nil {
+		return nil, err
+	}
+	switch cfg.Backend {
+	case "contract", "memory":
+	default:
+		return nil, fmt.Errorf("backend %q is not contract or memory", cfg.Backend)
+	}
+	if cfg.MemoryAdmin != "" && !common.IsHexAddress(cfg.MemoryAdmin) {
+		return nil, fmt.Errorf("memory admin %q is not an address", cfg.MemoryAdmin)
+	}
+	// Contract in memory has no deployer, so its admin is set explicitly.
+	if cfg.Backend == "memory" && cfg.MemoryAdmin == "" {
+		return nil, fmt.Errorf("memory backend needs memory admin")
+	}
+	if cfg.ChainId != "" && cfg.ChainIdValue() == nil {
+		return nil, fmt.Errorf("chain id %q is not a number", cfg.ChainId)
+	}
+	cfg.StaticPath = withSlash(cfg.StaticPath)
+	cfg.TemplatesPath = withSlash(cfg.TemplatesPath)
+	return cfg, nil
+}
+
+// Address from config has priority over the last deployment
+// to network in deployments file. Without deployment to network the
+// address file written by deploy before deployments file is used.
+func (cfg *ConfigType) ContractAddress(networkId *big.Int) (string, error) {
+	if cfg.Contract != "" {
+		return cfg.Contract, nil
+	}
+	deployments, err := ReadDeployments(cfg.Deployments)
+	if err != nil {
+		return "", err
+	}
+	deployment := LastDeployment(deployments, networkId)
+	if deployment != nil {
+		return deployment.Address.Hex(), nil
+	}
+	data, err := ioutil.ReadFile(cfg.ContractFile)
+	if err == nil && strings.TrimSpace(string(data)) != "" {
+		Logger.Printf("no deployment to network %s in %s, using contract from %s", networkId, cfg.Deployments, cfg.ContractFile)
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", fmt.Errorf("no deployment to network %s in %s, set -contract or run deploy", networkId, cfg.Deployments)
+}
+
+// Chain id expected from node, nil if any chain is accepted.
+func (cfg *ConfigType) ChainIdValue() *big.Int {
+	id, ok := new(big.Int).SetString(cfg.ChainId, 10)
+	if !ok || id.Sign() <= 0 {
+		return nil
+	}
+	return id
+}
+
+// How long to wait for transaction receipt, checked in LoadConfig.
+func (cfg *ConfigType) TxWait() time.Duration {
+	timeout, _ := time.ParseDuration(cfg.TxTimeout)
+	return timeout
+}
+
+// How often to look for new blocks, checked in LoadConfig.
+func (cfg *ConfigType) PollWait() time.Duration {
+	interval, _ := time.ParseDuration(cfg.PollInterval)
+	return interval
+}
+
+func (cfg *ConfigType) checkGas() error {
+	if cfg.GasLimit != "estimate" {
+		if limit, err := strconv.ParseUint(cfg.GasLimit, 10, 64); err != nil || limit == 0 {
+			return fmt.Errorf("gas limit %q is not estimate or number", cfg.GasLimit)
+		}
+	}
+	if mult, err := strconv.ParseFloat(cfg.GasMultiplier, 64); err != nil || mult < 1 {
+		return fmt.Errorf("gas multiplier %q is not a number >= 1", cfg.GasMultiplier)
+	}
+	if _, err := parseGasCaps(cfg.GasCaps); err != nil {
+		return err
+	}
+	switch cfg.Fees {
+	case "auto", "legacy", "eip1559":
+	default:
+		return fmt.Errorf("fees %q is not auto, legacy or eip1559", cfg.Fees)
+	}
+	for name, value := range map[string]string{"max fee": cfg.MaxFee, "priority fee": cfg.PriorityFee} {
+		if value == "" {
+			continue
+		}
+		if wei, ok := new(big.Int).SetString(value, 10); !ok || wei.Sign() <= 0 {
+			return fmt.Errorf("%s %q is not a number of wei", name, value)
+		}
+	}
+	return nil
+}
+
+// Caps are written as method=limit,method=limit.
+func parseGasCaps(caps string) (map[string]uint64, error) {
+	result := make(map[string]uint64)
+	for _, item := range strings.Split(caps, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf("gas cap %q is not method=limit", item)
+		}
+		limit, err := strconv.ParseUint(strings.TrimSpace(pair[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("gas cap %q is not method=limit", item)
+		}
+		result[strings.TrimSpace(pair[0])] = limit
+	}
+	return result, nil
+}
+
+// Fixed gas limit, 0 if it is estimated. Gas settings are checked in LoadConfig.
+func (cfg *ConfigType) FixedGasLimit() uint64 {
+	limit, _ := strconv.ParseUint(cfg.GasLimit, 10, 64)
+	return limit
+}
+
+func (cfg *ConfigType) GasMultiplierValue() float64 {
+	mult, _ := strconv.ParseFloat(cfg.GasMultiplier, 64)
+	return mult
+}
+
+// Cap of gas limit for method, 0 if it has no cap.
+func (cfg *ConfigType) GasCap(method string) uint64 {
+	caps, _ := parseGasCaps(cfg.GasCaps)
+	return caps[method]
+}
+
+// Max fee per gas (or max gas price of legacy fees), nil if it is not set.
+func (cfg *ConfigType) MaxFeeWei() *big.Int {
+	wei, ok := new(big.Int).SetString(cfg.MaxFee, 10)
+	if !ok {
+		return nil
+	}
+	return wei
+}
+
+// Priority fee per gas, nil if it is suggested by node.
+func (cfg *ConfigType) PriorityFeeWei() *big.Int {
+	wei, ok := new(big.Int).SetString(cfg.PriorityFee, 10)
+	if !ok {
+		return nil
+	}
+	return wei
+}
+
+func withSlash(path string) string {
+	if strings.HasSuffix(path, "/") {
+		return path
+	}
+	return path + "/"
+}
@@ -0,0 +1,324 @@
+package worldskills_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	contract "github.com/number571/contract-interfaces/contracts"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+// Gas of transactions which must revert. They are sent without estimate,
+// which fails for them, so they reach the contract and are mined.
+const REVERT_GAS = 300000
+
+// Present, sale and rent lifecycles run on MemoryRegistry and on deployed
+// contract. Calls refused by the contract must be refused by Preflight and
+// mined with failed receipt, so the memory backend follows contract.sol.
+type conformance struct {
+	users *chaintest.UsersType
+	bound *bind.BoundContract
+}
+
+func TestConformance(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(contract.ContractABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			users := chaintest.Setup(t, backend)
+			conf := &conformance{
+				users: users,
+				bound: bind.NewBoundContract(worldskills.ContractAddr, parsed, worldskills.ClientETH, worldskills.ClientETH, worldskills.ClientETH),
+			}
+			t.Run("presents", conf.presents)
+			t.Run("sales", conf.sales)
+			t.Run("rents", conf.rents)
+		})
+	}
+}
+
+// Run step as subtest, the rest of lifecycle depends on it.
+func step(t *testing.T, name string, run func(t *testing.T)) {
+	t.Helper()
+	if !t.Run(name, run) {
+		t.FailNow()
+	}
+}
+
+// Call of method by user must succeed.
+func (conf *conformance) succeeds(t *testing.T, name string, user *worldskills.UserType, value *big.Int, method string, send sendFunc, args ...interface{}) {
+	t.Helper()
+	step(t, name, func(t *testing.T) {
+		transact(t, user, value, method, send, args...)
+	})
+}
+
+// Call of method by user must revert: Preflight refuses it and, sent
+// anyway, it is mined with failed receipt.
+func (conf *conformance) reverts(t *testing.T, name string, user *worldskills.UserType, value *big.Int, method string, args ...interface{}) {
+	t.Helper()
+	step(t, name, func(t *testing.T) {
+		auth, err := worldskills.ResetAuth(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		auth.Value = value
+		if err := worldskills.Preflight(user, auth, method, args...); err == nil {
+			t.Fatalf("preflight accepts %s", method)
+		}
+		auth.GasLimit = REVERT_GAS
+		tx, err := worldskills.SendTx(auth, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+			return conf.bound.Transact(auth, method, args...)
+		})
+		if err != nil {
+			t.Fatalf("send %s: %v", method, err)
+		}
+		receipt, err := worldskills.WaitTx(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.Success {
+			t.Fatalf("tx %s of %s succeeded, contract reverts it", tx.Hash().Hex(), method)
+		}
+	})
+}
+
+func (conf *conformance) estate(t *testing.T, estateId *big.Int) *worldskills.Estate {
+	t.Helper()
+	estate, err := worldskills.Backend.GetEstates(conf.users.Admin, estateId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return estate
+}
+
+func (conf *conformance) balance(t *testing.T, user *worldskills.UserType) *big.Int {
+	t.Helper()
+	balance, err := worldskills.ClientETH.BalanceAt(context.Background(), user.AddressEth, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+// Balance is checked only for accounts which did not send the transaction,
+// so gas paid does not change it.
+func (conf *conformance) received(t *testing.T, name string, user *worldskills.UserType, before *big.Int, want int64) {
+	t.Helper()
+	step(t, name, func(t *testing.T) {
+		diff := new(big.Int).Sub(conf.balance(t, user), before)
+		if diff.Cmp(big.NewInt(want)) != 0 {
+			t.Fatalf("received %s wei, contract sends %d wei", diff, want)
+		}
+	})
+}
+
+// Events of the last transaction, mined into its own block on both backends,
+// must be the wanted ones. Value of wanted event is checked if it is set.
+func (conf *conformance) emitted(t *testing.T, name string, want ...*worldskills.EventType) {
+	t.Helper()
+	step(t, name, func(t *testing.T) {
+		head, err := worldskills.ClientETH.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := worldskills.FilterEvents(head, &head)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != len(want) {
+			t.Fatalf("%d events, contract emits %d", len(events), len(want))
+		}
+		for i, event := range events {
+			if event.Name != want[i].Name || event.From != want[i].From || event.To != want[i].To ||
+				(want[i].Value != nil && event.Value.Cmp(want[i].Value) != 0) {
+				t.Fatalf("event %s, contract emits %+v", event, want[i])
+			}
+		}
+	})
+}
+
+func (conf *conformance) number(t *testing.T, count func(*worldskills.UserType) (*big.Int, error)) *big.Int {
+	t.Helper()
+	number, err := count(conf.users.Admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return number
+}
+
+func (conf *conformance) presents(t *testing.T) {
+	var (
+		admin, first, second = conf.users.Admin, conf.users.First, conf.users.Second
+		estateId = createEstate(t, admin, first)
+		price = big.NewInt(1000)
+	)
+	conf.reverts(t, "only admin creates estates", second, nil, "create_estate", second.AddressEth, "test", big.NewInt(100), big.NewInt(80))
+	conf.reverts(t, "only owner presents estate", second, nil, "create_present", estateId, second.AddressEth)
+	presentId := conf.number(t, worldskills.Backend.PresentsNumber)
+	conf.succeeds(t, "owner presents estate", first, nil, "create_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreatePresent(auth, estateId, second.AddressEth)
+	}, estateId, second.AddressEth)
+	if !conf.estate(t, estateId).PresentStatus {
+		t.Fatal("presented estate has no present status")
+	}
+	conf.reverts(t, "presented estate can not be sold", first, nil, "create_sale", estateId, price)
+	conf.reverts(t, "only recipient confirms present", first, nil, "confirm_present", presentId)
+	conf.succeeds(t, "recipient confirms present", second, nil, "confirm_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ConfirmPresent(auth, presentId)
+	}, presentId)
+	present, err := worldskills.Backend.GetPresents(admin, presentId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estate := conf.estate(t, estateId); estate.Owner != second.AddressEth || estate.PresentStatus || !present.Finished {
+		t.Fatalf("confirmed present does not change owner: %+v %+v", estate, present)
+	}
+	conf.reverts(t, "finished present can not be cancelled", first, nil, "cancel_present", presentId)
+
+	presentId = conf.number(t, worldskills.Backend.PresentsNumber)
+	conf.succeeds(t, "new owner presents estate", second, nil, "create_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreatePresent(auth, estateId, first.AddressEth)
+	}, estateId, first.AddressEth)
+	conf.reverts(t, "only sender cancels present", first, nil, "cancel_present", presentId)
+	conf.succeeds(t, "sender cancels present", second, nil, "cancel_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelPresent(auth, presentId)
+	}, presentId)
+	if estate := conf.estate(t, estateId); estate.Owner != second.AddressEth || estate.PresentStatus {
+		t.Fatalf("cancelled present does not keep owner: %+v", estate)
+	}
+}
+
+func (conf *conformance) sales(t *testing.T) {
+	var (
+		admin, first, second = conf.users.Admin, conf.users.First, conf.users.Second
+		estateId = createEstate(t, admin, first)
+		saleId = conf.number(t, worldskills.Backend.SalesNumber)
+		price = big.NewInt(1000)
+	)
+	conf.succeeds(t, "owner puts estate on sale", first, nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateSale(auth, estateId, price)
+	}, estateId, price)
+	conf.reverts(t, "seller can not bid", first, price, "check_to_buy", saleId)
+	conf.reverts(t, "bid lower than price is refused", second, big.NewInt(999), "check_to_buy", saleId)
+	conf.succeeds(t, "customer bids", second, price, "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CheckToBuy(auth, saleId)
+	}, saleId)
+	conf.reverts(t, "customer can not bid twice", second, price, "check_to_buy", saleId)
+	conf.succeeds(t, "second customer bids", admin, big.NewInt(2000), "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CheckToBuy(auth, saleId)
+	}, saleId)
+	conf.reverts(t, "only seller confirms sale", second, nil, "confirm_sale", saleId, big.NewInt(1))
+	before := conf.balance(t, second)
+	conf.succeeds(t, "seller confirms sale to second customer", first, nil, "confirm_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ConfirmSale(auth, saleId, big.NewInt(1))
+	}, saleId, big.NewInt(1))
+	conf.emitted(t, "confirmed sale is emitted with price of chosen bid", &worldskills.EventType{
+		Name: "SaleConfirmed", From: first.AddressEth, To: admin.AddressEth, Value: big.NewInt(2000),
+	})
+	conf.received(t, "other bids are sent back", second, before, 1000)
+	sale, err := worldskills.Backend.GetSales(admin, saleId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estate := conf.estate(t, estateId); estate.Owner != admin.AddressEth || estate.SaleStatus || !sale.Finished {
+		t.Fatalf("sold estate does not belong to customer: %+v %+v", estate, sale)
+	}
+	conf.reverts(t, "finished sale can not be cancelled", first, nil, "cancel_sale", saleId)
+
+	estateId = createEstate(t, admin, first)
+	saleId = conf.number(t, worldskills.Backend.SalesNumber)
+	conf.succeeds(t, "owner puts another estate on sale", first, nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateSale(auth, estateId, price)
+	}, estateId, price)
+	conf.succeeds(t, "customer bids above price", second, big.NewInt(1500), "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CheckToBuy(auth, saleId)
+	}, saleId)
+	conf.succeeds(t, "second customer bids price", admin, price, "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CheckToBuy(auth, saleId)
+	}, saleId)
+	conf.succeeds(t, "second customer withdraws bid", admin, nil, "cancel_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelToBuy(auth, saleId)
+	}, saleId)
+	conf.emitted(t, "withdrawn bid is emitted", &worldskills.EventType{Name: "BidWithdrawn", From: admin.AddressEth})
+	conf.succeeds(t, "customer withdraws bid again", admin, nil, "cancel_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelToBuy(auth, saleId)
+	}, saleId)
+	conf.emitted(t, "bid is not withdrawn twice")
+	conf.reverts(t, "withdrawn bid can not be chosen", first, nil, "confirm_sale", saleId, big.NewInt(1))
+	conf.reverts(t, "customer who withdrew can not bid again", admin, price, "check_to_buy", saleId)
+	before = conf.balance(t, second)
+	conf.succeeds(t, "seller cancels sale", first, nil, "cancel_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelSale(auth, saleId)
+	}, saleId)
+	conf.received(t, "cancelled sale sends bids back", second, before, 1500)
+	if estate := conf.estate(t, estateId); estate.Owner != first.AddressEth || estate.SaleStatus {
+		t.Fatalf("cancelled sale does not keep owner: %+v", estate)
+	}
+}
+
+func (conf *conformance) rents(t *testing.T) {
+	var (
+		admin, first, second = conf.users.Admin, conf.users.First, conf.users.Second
+		estateId = createEstate(t, admin, first)
+		zero, day = big.NewInt(0), big.NewInt(1)
+		price = big.NewInt(500)
+	)
+	conf.reverts(t, "only owner rents out estate", second, nil, "create_rent", estateId, zero, price)
+	rentId := conf.number(t, worldskills.Backend.RentsNumber)
+	conf.succeeds(t, "owner rents out estate", first, nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateRent(auth, estateId, zero, price)
+	}, estateId, zero, price)
+	conf.reverts(t, "estate in rent can not be sold", first, nil, "create_sale", estateId, big.NewInt(1000))
+	conf.reverts(t, "owner can not take own rent", first, price, "to_rent", rentId)
+	conf.reverts(t, "rent is paid exactly", second, big.NewInt(400), "to_rent", rentId)
+	before := conf.balance(t, first)
+	conf.succeeds(t, "renter takes rent", second, price, "to_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ToRent(auth, rentId)
+	}, rentId)
+	conf.received(t, "owner is paid for rent", first, before, 500)
+	rent, err := worldskills.Backend.GetRents(admin, rentId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estate := conf.estate(t, estateId); rent.RenterAddress != second.AddressEth || estate.RenterAddress != second.AddressEth || rent.Deadline.Sign() == 0 {
+		t.Fatalf("taken rent has no renter or deadline: %+v %+v", estate, rent)
+	}
+	conf.reverts(t, "taken rent can not be cancelled", first, nil, "cancel_rent", rentId)
+	conf.reverts(t, "only owner of estate finishes rent", second, nil, "finish_rent", rentId)
+	// Rent of 0 days ends with the block it is taken in.
+	chaintest.Mine(t)
+	conf.succeeds(t, "owner finishes rent after deadline", first, nil, "finish_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.FinishRent(auth, rentId)
+	}, rentId)
+	if rent, err = worldskills.Backend.GetRents(admin, rentId); err != nil {
+		t.Fatal(err)
+	}
+	if estate := conf.estate(t, estateId); estate.RentStatus || estate.RenterAddress != (common.Address{}) || !rent.Finished {
+		t.Fatalf("finished rent does not free estate: %+v %+v", estate, rent)
+	}
+
+	rentId = conf.number(t, worldskills.Backend.RentsNumber)
+	conf.succeeds(t, "owner rents out estate again", first, nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateRent(auth, estateId, day, price)
+	}, estateId, day, price)
+	conf.succeeds(t, "owner cancels rent which is not taken", first, nil, "cancel_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CancelRent(auth, rentId)
+	}, rentId)
+	rentId = conf.number(t, worldskills.Backend.RentsNumber)
+	conf.succeeds(t, "owner rents out estate for a day", first, nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateRent(auth, estateId, day, price)
+	}, estateId, day, price)
+	conf.succeeds(t, "renter takes rent for a day", second, price, "to_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.ToRent(auth, rentId)
+	}, rentId)
+	conf.reverts(t, "rent is not finished before deadline", first, nil, "finish_rent", rentId)
+}
@@ -0,0 +1,166 @@
+package worldskills
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"bytes"
+	"errors"
+	"context"
+	"math/big"
+	"io/ioutil"
+	"encoding/json"
+	"path/filepath"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Mined deployment of contract, Timestamp is time of its block.
+type DeploymentType struct {
+	NetworkId *big.Int `json:"network_id"`
+	Address common.Address `json:"address"`
+	TxHash common.Hash `json:"tx_hash"`
+	Block uint64 `json:"block"`
+	Deployer common.Address `json:"deployer"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Deployments file is a JSON array in order of recording,
+// missing file has no deployments.
+func ReadDeployments(filename string) ([]*DeploymentType, error) {
+	data, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read deployments: %w", err)
+	}
+	var deployments []*DeploymentType
+	if err := json.Unmarshal(data, &deployments); err != nil {
+		return nil, fmt.Errorf("read deployments %s: %w", filename, err)
+	}
+	return deployments, nil
+}
+
+// Append deployment to file, unless its tx is already recorded.
+// File is replaced at once, so it is never left half written.
+func SaveDeployment(filename string, deployment *DeploymentType) error {
+	deployments, err := ReadDeployments(filename)
+	if err != nil {
+		return err
+	}
+	for _, saved := range deployments {
+		if saved.TxHash == deployment.TxHash {
+			return nil
+		}
+	}
+	deployments = append(deployments, deployment)
+	data, err := json.MarshalIndent(deployments, "", "\t")
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(filename), ".deployments-")
+	if err != nil {
+		return fmt.Errorf("write deployments: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		temp.Close()
+		return fmt.Errorf("write deployments: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("write deployments: %w", err)
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return fmt.Errorf("write deployments: %w", err)
+	}
+	if err := os.Rename(temp.Name(), filename); err != nil {
+		return fmt.Errorf("write deployments: %w", err)
+	}
+	return nil
+}
+
+// The last recorded deployment to network, nil if there is none.
+func LastDeployment(deployments []*DeploymentType, networkId *big.Int) *DeploymentType {
+	for i := len(deployments) - 1; i >= 0; i-- {
+		if deployments[i].NetworkId != nil && deployments[i].NetworkId.Cmp(networkId) == 0 {
+			return deployments[i]
+		}
+	}
+	return nil
+}
+
+// Wait until deployment tx is mined, but not longer than Config.TxTimeout,
+// and check that contract has code at its address.
+func WaitDeployment(tx *types.Transaction) (*DeploymentType, error) {
+	if tx.To() != nil {
+		return nil, fmt.Errorf("tx %s does not create contract", tx.Hash().Hex())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Config.TxWait())
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, ClientETH, tx)
+	if err != nil {
+		return nil, fmt.Errorf("wait tx %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("deployment tx %s reverted", tx.Hash().Hex())
+	}
+	code, err := ClientETH.CodeAt(context.Background(), receipt.ContractAddress, receipt.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("get code of contract %s: %w", receipt.ContractAddress.Hex(), err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no contract code at address %s after deployment", receipt.ContractAddress.Hex())
+	}
+	header, err := ClientETH.HeaderByNumber(context.Background(), receipt.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("get block %s: %w", receipt.BlockNumber, err)
+	}
+	deployer, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("get sender of tx %s: %w", tx.Hash().Hex(), err)
+	}
+	networkId, err := ClientETH.NetworkID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("get network id: %w", err)
+	}
+	return &DeploymentType{
+		NetworkId: networkId,
+		Address: receipt.ContractAddress,
+		TxHash: tx.Hash(),
+		Block: receipt.BlockNumber.Uint64(),
+		Deployer: deployer,
+		Timestamp: time.Unix(int64(header.Time), 0).UTC(),
+	}, nil
+}
+
+// Runtime code on chain is a part of creation code built by solc, after
+// constructor. Code which differs only in metadata appended by solc (hash
+// of sources and compiler settings) is reported as metadata match.
+func CompareCode(runtime []byte, creation []byte) (match bool, metadata bool) {
+	if len(runtime) == 0 {
+		return false, false
+	}
+	if bytes.Contains(creation, runtime) {
+		return true, false
+	}
+	stripped := stripMetadata(runtime)
+	if len(stripped) == len(runtime) {
+		return false, false
+	}
+	match = bytes.Contains(creation, stripped)
+	return match, match
+}
+
+// Metadata is CBOR at the end of code, its length is in the last two bytes.
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if length+2 > len(code) {
+		return code
+	}
+	return code[:len(code)-length-2]
+}
@@ -0,0 +1,52 @@
+package worldskills
+
+import (
+	"os"
+	"fmt"
+	"log"
+	"errors"
+	"io/ioutil"
+)
+
+// Exit codes of deploy, client and gclient.
+const (
+	EXIT_FAILURE  = 1
+	EXIT_USAGE    = 2
+	EXIT_CONFIG   = 3
+	EXIT_CONNECT  = 4
+	EXIT_CONTRACT = 5
+	EXIT_USER     = 6
+	EXIT_TX       = 7
+)
+
+// Errors which do not stop the program, like a failed poll of Index,
+// are written to Logger. Commands set it, by default they are dropped.
+var Logger = log.New(ioutil.Discard, "", 0)
+
+type ExitError struct {
+	Code int
+	Err error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Wrap error err with exit code.
+func WithExit(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// Print error and exit with its code, EXIT_FAILURE if it has none.
+func Fatal(err error) {
+	fmt.Fprintln(os.Stderr, "failed:", err)
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
+	os.Exit(EXIT_FAILURE)
+}
@@ -0,0 +1,109 @@
+package worldskills
+
+import (
+	"fmt"
+	"math"
+	"context"
+	"math/big"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Set gas limit and fees of auth for method ("deploy" for contract creation)
+// by gas settings of Config. Limit is fixed or estimated with multiplier,
+// then limited by cap of method. Fees are EIP-1559 if node reports base fee
+// and fees are "auto", otherwise legacy gas price.
+func SetGas(auth *bind.TransactOpts, method string, to *common.Address, data []byte) error {
+	limit := Config.FixedGasLimit()
+	if limit == 0 {
+		estimated, err := ClientETH.EstimateGas(context.Background(), ethereum.CallMsg{
+			From: auth.From,
+			To: to,
+			Value: auth.Value,
+			Data: data,
+		})
+		if err != nil {
+			return fmt.Errorf("estimate gas of %s: %w", method, err)
+		}
+		limit = uint64(math.Ceil(float64(estimated) * Config.GasMultiplierValue()))
+		if limitCap := Config.GasCap(method); limitCap != 0 && estimated > limitCap {
+			return fmt.Errorf("%s needs %d gas, above cap %d", method, estimated, limitCap)
+		}
+	}
+	if limitCap := Config.GasCap(method); limitCap != 0 && limit > limitCap {
+		limit = limitCap
+	}
+	auth.GasLimit = limit
+	return setFees(auth)
+}
+
+func setFees(auth *bind.TransactOpts) error {
+	var (
+		maxFee = Config.MaxFeeWei()
+		baseFee *big.Int
+	)
+	if Config.Fees != "legacy" {
+		header, err := ClientETH.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return fmt.Errorf("get latest block: %w", err)
+		}
+		baseFee = header.BaseFee
+		if baseFee == nil && Config.Fees == "eip1559" {
+			return fmt.Errorf("node does not support EIP-1559 fees, set fees to legacy")
+		}
+	}
+	if baseFee == nil {
+		gasPrice, err := ClientETH.SuggestGasPrice(context.Background())
+		if err != nil {
+			return fmt.Errorf("get gas price: %w", err)
+		}
+		if maxFee != nil && gasPrice.Cmp(maxFee) > 0 {
+			return fmt.Errorf("gas price %s wei is above max fee %s wei", gasPrice, maxFee)
+		}
+		auth.GasPrice = gasPrice
+		return nil
+	}
+	tip := Config.PriorityFeeWei()
+	if tip == nil {
+		var err error
+		tip, err = ClientETH.SuggestGasTipCap(context.Background())
+		if err != nil {
+			return fmt.Errorf("get priority fee: %w", err)
+		}
+	}
+	feeCap := maxFee
+	if feeCap == nil {
+		feeCap = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	}
+	if feeCap.Cmp(new(big.Int).Add(baseFee, tip)) < 0 {
+		return fmt.Errorf("max fee %s wei is below base fee %s wei and priority fee %s wei", feeCap, baseFee, tip)
+	}
+	auth.GasPrice = nil
+	auth.GasFeeCap = feeCap
+	auth.GasTipCap = tip
+	return nil
+}
+
+// Most the transaction of auth can cost in wei.
+func GasCost(auth *bind.TransactOpts) *big.Int {
+	price := auth.GasPrice
+	if auth.GasFeeCap != nil {
+		price = auth.GasFeeCap
+	}
+	cost := new(big.Int)
+	if price != nil {
+		cost.Mul(price, new(big.Int).SetUint64(auth.GasLimit))
+	}
+	if auth.Value != nil {
+		cost.Add(cost, auth.Value)
+	}
+	return cost
+}
+
+func GasString(auth *bind.TransactOpts) string {
+	if auth.GasFeeCap != nil {
+		return fmt.Sprintf("Gas: %d, max fee: %s wei, priority fee: %s wei, max cost: %s wei", auth.GasLimit, auth.GasFeeCap, auth.GasTipCap, GasCost(auth))
+	}
+	return fmt.Sprintf("Gas: %d, price: %s wei, max cost: %s wei", auth.GasLimit, auth.GasPrice, GasCost(auth))
+}
@@ -0,0 +1,438 @@
+package worldskills
+
+import (
+	"fmt"
+	"errors"
+	"sync"
+	"time"
+	"context"
+	"math/big"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Number of last blocks which are remembered to roll back after reorg.
+const (
+	REORG_DEPTH = 128
+)
+
+// Local view of contract state. It is loaded once and then updated only
+// for records named in events of new blocks.
+type IndexerType struct {
+	mutex sync.RWMutex
+	store *StoreType
+	lastBlock uint64
+	blocks map[uint64]*blockRecord
+	estates []*Estate
+	presents []*Present
+	sales []*Sale
+	rents []*Rent
+	err error
+	stop chan struct{}
+	stopped chan struct{}
+}
+
+// Hash of block and records changed by its events.
+type blockRecord struct {
+	Hash common.Hash
+	Estates []uint64
+	Presents []uint64
+	Sales []uint64
+	Rents []uint64
+}
+
+// Getters of contract do not depend on caller.
+var indexerUser = &UserType{}
+
+var (
+	Index *IndexerType
+)
+
+// Load state from Config.CacheFile if it is set and catch up with chain,
+// otherwise load full state. Then keep it updated every Config.PollInterval.
+func StartIndexer() (*IndexerType, error) {
+	idx := &IndexerType{
+		blocks: make(map[uint64]*blockRecord),
+		stop: make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if Config.CacheFile != "" {
+		store, err := openStore(Config.CacheFile, ContractAddr)
+		if err != nil {
+			return nil, err
+		}
+		state, err := store.Load()
+		if err != nil {
+			store.Close()
+			return nil, err
+		}
+		idx.store = store
+		idx.lastBlock = state.lastBlock
+		idx.blocks = state.blocks
+		idx.estates = state.estates
+		idx.presents = state.presents
+		idx.sales = state.sales
+		idx.rents = state.rents
+	}
+	var err error
+	if idx.lastBlock == 0 {
+		err = idx.load()
+	} else {
+		err = idx.update()
+	}
+	if err != nil {
+		if idx.store != nil {
+			idx.store.Close()
+		}
+		return nil, err
+	}
+	go idx.poll()
+	return idx, nil
+}
+
+func (idx *IndexerType) poll() {
+	defer close(idx.stopped)
+	ticker := time.NewTicker(Config.PollWait())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-idx.stop:
+			return
+		case <-ticker.C:
+			err := idx.update()
+			if err != nil {
+				Logger.Println("indexer:", err)
+			}
+			idx.mutex.Lock()
+			idx.err = err
+			idx.mutex.Unlock()
+		}
+	}
+}
+
+// Stop polling after the current update and close cache,
+// records stay readable.
+func (idx *IndexerType) Stop() {
+	close(idx.stop)
+	<-idx.stopped
+	if idx.store != nil {
+		idx.store.Close()
+	}
+}
+
+// Read every record of contract.
+func (idx *IndexerType) load() error {
+	head, err := ClientETH.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("get block number: %w", err)
+	}
+	dirty := newDirtySet()
+	dirty.all = true
+	idx.blocks = make(map[uint64]*blockRecord)
+	if err := idx.record(head, head, nil); err != nil {
+		return err
+	}
+	if err := idx.apply(dirty); err != nil {
+		return err
+	}
+	idx.lastBlock = head
+	return idx.save(dirty)
+}
+
+// Read events after lastBlock and refresh records named in them.
+// Records changed by blocks dropped in reorg are refreshed too.
+func (idx *IndexerType) update() error {
+	head, err := ClientETH.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("get block number: %w", err)
+	}
+	dirty := newDirtySet()
+	safe, ok, err := idx.rollback(dirty)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return idx.load()
+	}
+	if head <= safe && safe == idx.lastBlock {
+		return nil
+	}
+	var events []*EventType
+	if head > safe {
+		events, err = FilterEvents(safe+1, &head)
+		if err != nil {
+			return err
+		}
+	}
+	for _, event := range events {
+		dirty.touch(event)
+	}
+	if err := idx.record(safe+1, head, events); err != nil {
+		return err
+	}
+	if err := idx.apply(dirty); err != nil {
+		return err
+	}
+	idx.lastBlock = head
+	return idx.save(dirty)
+}
+
+// Find the last remembered block which is still in chain and mark records
+// changed after it. Not ok if no remembered block is left in chain.
+func (idx *IndexerType) rollback(dirty *dirtySet) (uint64, bool, error) {
+	number := idx.lastBlock
+	for {
+		block, ok := idx.blocks[number]
+		if !ok {
+			// Nothing is remembered, which is fine only for an old cache
+			// without blocks. Reorg deeper than REORG_DEPTH needs full load.
+			return number, number == idx.lastBlock && len(idx.blocks) == 0, nil
+		}
+		header, err := ClientETH.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			return 0, false, fmt.Errorf("get block %d: %w", number, err)
+		}
+		if err == nil && header.Hash() == block.Hash {
+			return number, true, nil
+		}
+		dirty.add(block)
+		delete(idx.blocks, number)
+		if number == 0 {
+			return 0, false, nil
+		}
+		number--
+	}
+}
+
+// Remember hashes and changed records of blocks from..to, only the last
+// REORG_DEPTH blocks are kept.
+func (idx *IndexerType) record(from uint64, to uint64, events []*EventType) error {
+	if to+1 > REORG_DEPTH && from < to+1-REORG_DEPTH {
+		from = to + 1 - REORG_DEPTH
+	}
+	for number := from; number <= to && from <= to; number++ {
+		header, err := ClientETH.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+		if err != nil {
+			return fmt.Errorf("get block %d: %w", number, err)
+		}
+		idx.blocks[number] = &blockRecord{Hash: header.Hash()}
+	}
+	for _, event := range events {
+		block, ok := idx.blocks[event.BlockNumber]
+		if !ok {
+			continue
+		}
+		block.Estates = append(block.Estates, event.EstateId.Uint64())
+		switch event.Record {
+		case "present":
+			block.Presents = append(block.Presents, event.Id.Uint64())
+		case "sale":
+			block.Sales = append(block.Sales, event.Id.Uint64())
+		case "rent":
+			block.Rents = append(block.Rents, event.Id.Uint64())
+		}
+	}
+	for number := range idx.blocks {
+		if number+REORG_DEPTH <= to {
+			delete(idx.blocks, number)
+		}
+	}
+	return nil
+}
+
+func (idx *IndexerType) save(dirty *dirtySet) error {
+	if idx.store == nil {
+		return nil
+	}
+	idx.mutex.RLock()
+	state := &storeState{
+		lastBlock: idx.lastBlock,
+		blocks: idx.blocks,
+		estates: idx.estates,
+		presents: idx.presents,
+		sales: idx.sales,
+		rents: idx.rents,
+	}
+	idx.mutex.RUnlock()
+	return idx.store.Save(state, dirty)
+}
+
+type dirtySet struct {
+	all bool
+	estates map[uint64]bool
+	presents map[uint64]bool
+	sales map[uint64]bool
+	rents map[uint64]bool
+}
+
+func newDirtySet() *dirtySet {
+	return &dirtySet{
+		estates: make(map[uint64]bool),
+		presents: make(map[uint64]bool),
+		sales: make(map[uint64]bool),
+		rents: make(map[uint64]bool),
+	}
+}
+
+// Mark records which event changed.
+func (dirty *dirtySet) touch(event *EventType) {
+	dirty.estates[event.EstateId.Uint64()] = true
+	switch event.Record {
+	case "present":
+		dirty.presents[event.Id.Uint64()] = true
+	case "sale":
+		dirty.sales[event.Id.Uint64()] = true
+	case "rent":
+		dirty.rents[event.Id.Uint64()] = true
+	}
+}
+
+// Mark records which block changed.
+func (dirty *dirtySet) add(block *blockRecord) {
+	for _, i := range block.Estates {
+		dirty.estates[i] = true
+	}
+	for _, i := range block.Presents {
+		dirty.presents[i] = true
+	}
+	for _, i := range block.Sales {
+		dirty.sales[i] = true
+	}
+	for _, i := range block.Rents {
+		dirty.rents[i] = true
+	}
+}
+
+// Read new and dirty records from contract and store them. Records beyond
+// the numbers on chain, possible after reorg, are dropped.
+func (idx *IndexerType) apply(dirty *dirtySet) error {
+	estatesNum, err := Backend.EstatesNumber(indexerUser)
+	if err != nil {
+		return fmt.Errorf("get estates number: %w", err)
+	}
+	presentsNum, err := Backend.PresentsNumber(indexerUser)
+	if err != nil {
+		return fmt.Errorf("get presents number: %w", err)
+	}
+	salesNum, err := Backend.SalesNumber(indexerUser)
+	if err != nil {
+		return fmt.Errorf("get sales number: %w", err)
+	}
+	rentsNum, err := Backend.RentsNumber(indexerUser)
+	if err != nil {
+		return fmt.Errorf("get rents number: %w", err)
+	}
+
+	idx.mutex.RLock()
+	estates := append([]*Estate(nil), idx.estates...)
+	presents := append([]*Present(nil), idx.presents...)
+	sales := append([]*Sale(nil), idx.sales...)
+	rents := append([]*Rent(nil), idx.rents...)
+	idx.mutex.RUnlock()
+
+	if dirty.all {
+		estates, presents, sales, rents = nil, nil, nil, nil
+	}
+	if uint64(len(estates)) > estatesNum.Uint64() {
+		estates = estates[:estatesNum.Uint64()]
+	}
+	if uint64(len(presents)) > presentsNum.Uint64() {
+		presents = presents[:presentsNum.Uint64()]
+	}
+	if uint64(len(sales)) > salesNum.Uint64() {
+		sales = sales[:salesNum.Uint64()]
+	}
+	if uint64(len(rents)) > rentsNum.Uint64() {
+		rents = rents[:rentsNum.Uint64()]
+	}
+	for i := uint64(len(estates)); i < estatesNum.Uint64(); i++ {
+		estates = append(estates, nil)
+		dirty.estates[i] = true
+	}
+	for i := uint64(len(presents)); i < presentsNum.Uint64(); i++ {
+		presents = append(presents, nil)
+		dirty.presents[i] = true
+	}
+	for i := uint64(len(sales)); i < salesNum.Uint64(); i++ {
+		sales = append(sales, nil)
+		dirty.sales[i] = true
+	}
+	for i := uint64(len(rents)); i < rentsNum.Uint64(); i++ {
+		rents = append(rents, nil)
+		dirty.rents[i] = true
+	}
+
+	for i := range dirty.estates {
+		if i >= uint64(len(estates)) {
+			continue
+		}
+		if estates[i], err = Backend.GetEstates(indexerUser, new(big.Int).SetUint64(i)); err != nil {
+			return err
+		}
+	}
+	for i := range dirty.presents {
+		if i >= uint64(len(presents)) {
+			continue
+		}
+		if presents[i], err = Backend.GetPresents(indexerUser, new(big.Int).SetUint64(i)); err != nil {
+			return err
+		}
+	}
+	for i := range dirty.sales {
+		if i >= uint64(len(sales)) {
+			continue
+		}
+		if sales[i], err = Backend.GetSales(indexerUser, new(big.Int).SetUint64(i)); err != nil {
+			return err
+		}
+	}
+	for i := range dirty.rents {
+		if i >= uint64(len(rents)) {
+			continue
+		}
+		if rents[i], err = Backend.GetRents(indexerUser, new(big.Int).SetUint64(i)); err != nil {
+			return err
+		}
+	}
+
+	idx.mutex.Lock()
+	idx.estates = estates
+	idx.presents = presents
+	idx.sales = sales
+	idx.rents = rents
+	idx.mutex.Unlock()
+	return nil
+}
+
+// Error of the last poll, nil if records are up to date with it.
+func (idx *IndexerType) Err() error {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return idx.err
+}
+
+// Accessors return copies of slices. Records are replaced on update, never
+// changed, so they can be used without lock.
+func (idx *IndexerType) Estates() []*Estate {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return append([]*Estate(nil), idx.estates...)
+}
+
+func (idx *IndexerType) Presents() []*Present {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return append([]*Present(nil), idx.presents...)
+}
+
+func (idx *IndexerType) Sales() []*Sale {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return append([]*Sale(nil), idx.sales...)
+}
+
+func (idx *IndexerType) Rents() []*Rent {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return append([]*Rent(nil), idx.rents...)
+}
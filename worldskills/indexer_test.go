@@ -0,0 +1,50 @@
+package worldskills_test
+
+import (
+	"context"
+	"testing"
+	"path/filepath"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+// Records dropped by reorg must not come back from cache on restart.
+func TestIndexerReorgReload(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "cache.db")
+	users := chaintest.Setup(t, "contract", "-cache:"+cache)
+	chain := worldskills.ClientETH.(*chaintest.ChainType)
+
+	createEstate(t, users.Admin, users.First)
+	parent := chain.Blockchain().CurrentBlock()
+	createEstate(t, users.Admin, users.First)
+	createEstate(t, users.Admin, users.Second)
+	restart := func() []*worldskills.Estate {
+		t.Helper()
+		index, err := worldskills.StartIndexer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		index.Stop()
+		return index.Estates()
+	}
+	if estates := restart(); len(estates) != 3 {
+		t.Fatalf("index has %d estates before reorg, want 3", len(estates))
+	}
+
+	// Blocks of the last two estates are replaced by longer chain.
+	if err := chain.Fork(context.Background(), parent.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		chain.Commit()
+	}
+	if number := chain.Blockchain().CurrentBlock().NumberU64(); number != parent.NumberU64()+3 {
+		t.Fatalf("head is block %d after reorg, want %d", number, parent.NumberU64()+3)
+	}
+	if estates := restart(); len(estates) != 1 {
+		t.Fatalf("index has %d estates after reorg, want 1", len(estates))
+	}
+	if estates := restart(); len(estates) != 1 || estates[0].Owner != users.First.AddressEth {
+		t.Fatalf("index has %d estates loaded from cache after reorg, want 1 of first user", len(estates))
+	}
+}
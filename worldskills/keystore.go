@@ -0,0 +1,92 @@
+package worldskills
+
+import (
+	"os"
+	"fmt"
+	"errors"
+	"strings"
+	"io/ioutil"
+	"crypto/ecdsa"
+	"golang.org/x/term"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+// Keystore files are Web3 Secret Storage JSON, the same format geth uses.
+func DecryptKeystore(keyjson []byte, passphrase string) (*ecdsa.PrivateKey, error) {
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+func ReadKeystore(path string, passphrase string) (*ecdsa.PrivateKey, error) {
+	keyjson, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKeystore(keyjson, passphrase)
+}
+
+// Create new account in directory dir.
+func NewKeystore(dir string, passphrase string) (common.Address, error) {
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.NewAccount(passphrase)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
+
+// Import hex private key (purse) into directory dir.
+func ImportKeystore(dir string, purse string, passphrase string) (common.Address, error) {
+	priv, err := crypto.HexToECDSA(purse)
+	if err != nil {
+		return common.Address{}, err
+	}
+	ks := keystore.NewKeyStore(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+	account, err := ks.ImportECDSA(priv, passphrase)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
+
+// Passphrase is read from passfile if it is set, otherwise from terminal.
+func ReadPassphrase(passfile string, confirm bool) (string, error) {
+	if passfile != "" {
+		data, err := ioutil.ReadFile(passfile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	passphrase, err := InputPassword("Passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("%w, use -passfile:<path> without terminal", err)
+	}
+	if !confirm {
+		return passphrase, nil
+	}
+	repeat, err := InputPassword("Repeat passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("%w, use -passfile:<path> without terminal", err)
+	}
+	if passphrase != repeat {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// Read line from terminal without echo. It fails if stdin is not a terminal.
+func InputPassword(begin string) (string, error) {
+	fmt.Print(begin)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("read from terminal: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,194 @@
+package worldskills_test
+
+import (
+	"testing"
+	"strings"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+var backends = []string{"memory", "contract"}
+
+type sendFunc func(auth *bind.TransactOpts) (*types.Transaction, error)
+
+// Preflight, send and wait for tx like client does, it must succeed.
+func transact(t *testing.T, user *worldskills.UserType, value *big.Int, method string, send sendFunc, args ...interface{}) *worldskills.ReceiptType {
+	t.Helper()
+	auth, err := worldskills.ResetAuth(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.Value = value
+	if err := worldskills.Preflight(user, auth, method, args...); err != nil {
+		t.Fatalf("preflight %s: %v", method, err)
+	}
+	tx, err := worldskills.SendTx(auth, send)
+	if err != nil {
+		t.Fatalf("send %s: %v", method, err)
+	}
+	receipt, err := worldskills.WaitTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !receipt.Success {
+		t.Fatalf("%s reverted: %s", method, receipt)
+	}
+	return receipt
+}
+
+// Preflight of method must be refused with reason.
+func refused(t *testing.T, user *worldskills.UserType, value *big.Int, method string, reason string, args ...interface{}) {
+	t.Helper()
+	auth, err := worldskills.ResetAuth(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.Value = value
+	err = worldskills.Preflight(user, auth, method, args...)
+	if err == nil {
+		t.Fatalf("preflight accepts %s", method)
+	}
+	if !strings.Contains(err.Error(), reason) {
+		t.Fatalf("preflight %s: %q, want %q", method, err, reason)
+	}
+}
+
+func createEstate(t *testing.T, admin *worldskills.UserType, owner *worldskills.UserType) *big.Int {
+	t.Helper()
+	estateId, err := worldskills.Backend.EstatesNumber(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transact(t, admin, nil, "create_estate", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+		return worldskills.Backend.CreateEstate(auth, owner.AddressEth, "test", big.NewInt(100), big.NewInt(80))
+	}, owner.AddressEth, "test", big.NewInt(100), big.NewInt(80))
+	return estateId
+}
+
+// Estate is presented to Second, sold back to First and rented to Second.
+// Index, history and events must show each step.
+func TestLifecycles(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			users := chaintest.Setup(t, backend)
+			estateId := createEstate(t, users.Admin, users.First)
+			zero := big.NewInt(0)
+
+			transact(t, users.First, nil, "create_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreatePresent(auth, estateId, users.Second.AddressEth)
+			}, estateId, users.Second.AddressEth)
+			transact(t, users.Second, nil, "confirm_present", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ConfirmPresent(auth, zero)
+			}, zero)
+
+			transact(t, users.Second, nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateSale(auth, estateId, big.NewInt(1000))
+			}, estateId, big.NewInt(1000))
+			refused(t, users.First, big.NewInt(999), "check_to_buy", "bid 999 is lower than price 1000", zero)
+			refused(t, users.First, nil, "check_to_buy", "bid 0 is lower than price 1000", zero)
+			transact(t, users.First, big.NewInt(1000), "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CheckToBuy(auth, zero)
+			}, zero)
+			refused(t, users.Second, nil, "confirm_sale", "sale 0 has no customer -1", zero, big.NewInt(-1))
+			transact(t, users.Second, nil, "confirm_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ConfirmSale(auth, zero, zero)
+			}, zero, zero)
+
+			transact(t, users.First, nil, "create_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateRent(auth, estateId, zero, big.NewInt(500))
+			}, estateId, zero, big.NewInt(500))
+			value, err := worldskills.RentValue(users.Second, zero)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value.Cmp(big.NewInt(500)) != 0 {
+				t.Fatalf("rent value %s, want 500", value)
+			}
+			refused(t, users.Second, big.NewInt(400), "to_rent", "rent 0 costs exactly 500 wei, not 400", zero)
+			refused(t, users.Second, nil, "to_rent", "rent 0 costs exactly 500 wei, not 0", zero)
+			transact(t, users.Second, value, "to_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.ToRent(auth, zero)
+			}, zero)
+			chaintest.Mine(t)
+			transact(t, users.First, nil, "finish_rent", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.FinishRent(auth, zero)
+			}, zero)
+
+			index, err := worldskills.StartIndexer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			worldskills.Index = index
+			estates := index.Estates()
+			if len(estates) != 1 || estates[0].Owner != users.First.AddressEth {
+				t.Fatalf("estates of index %+v, want one of first user", estates)
+			}
+			if estate := estates[0]; estate.PresentStatus || estate.SaleStatus || estate.RentStatus || estate.RenterAddress != (common.Address{}) {
+				t.Fatalf("estate of index %+v has status after lifecycles", estate)
+			}
+			presents, sales, rents := index.Presents(), index.Sales(), index.Rents()
+			if len(presents) != 1 || !presents[0].Finished || len(sales) != 1 || !sales[0].Finished || len(rents) != 1 || !rents[0].Finished {
+				t.Fatalf("index has unfinished records: %+v %+v %+v", presents, sales, rents)
+			}
+
+			history, err := worldskills.GetEstateHistory(estateId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []struct{ name string; owner common.Address }{
+				{"EstateCreated", users.First.AddressEth},
+				{"PresentConfirmed", users.Second.AddressEth},
+				{"SaleConfirmed", users.First.AddressEth},
+				{"RentTaken", users.First.AddressEth},
+				{"RentFinished", users.First.AddressEth},
+			}
+			if len(history) != len(want) {
+				t.Fatalf("history has %d steps, want %d", len(history), len(want))
+			}
+			for i, step := range history {
+				if step.Event.Name != want[i].name || step.Owner != want[i].owner {
+					t.Errorf("history step %d is %s of %s, want %s of %s", i, step.Event.Name, step.Owner.Hex(), want[i].name, want[i].owner.Hex())
+				}
+			}
+		})
+	}
+}
+
+// Withdrawal without bid changes nothing and emits no event.
+func TestBidWithdrawnOnce(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend, func(t *testing.T) {
+			users := chaintest.Setup(t, backend)
+			estateId := createEstate(t, users.Admin, users.First)
+			zero := big.NewInt(0)
+			transact(t, users.First, nil, "create_sale", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateSale(auth, estateId, big.NewInt(1000))
+			}, estateId, big.NewInt(1000))
+			transact(t, users.Second, big.NewInt(1000), "check_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CheckToBuy(auth, zero)
+			}, zero)
+			for i := 0; i < 2; i++ {
+				transact(t, users.Second, nil, "cancel_to_buy", func(auth *bind.TransactOpts) (*types.Transaction, error) {
+					return worldskills.Backend.CancelToBuy(auth, zero)
+				}, zero)
+			}
+			events, err := worldskills.FilterEvents(0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			withdrawn := 0
+			for _, event := range events {
+				if event.Name == "BidWithdrawn" {
+					withdrawn++
+				}
+			}
+			if withdrawn != 1 {
+				t.Fatalf("%d BidWithdrawn events, want 1", withdrawn)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package worldskills
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"math/big"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	contract "github.com/number571/contract-interfaces/contracts"
+)
+
+// Node side of MemoryRegistry: blocks with one transaction each, receipts
+// with logs of contract events, nonces and balances of accounts.
+
+// Block with its only transaction, genesis block has none.
+type memoryBlock struct {
+	header *types.Header
+	tx *types.Transaction
+	receipt *types.Receipt
+}
+
+// Time of the next block, later than the last one like on chain.
+func (mem *MemoryRegistry) blockTime() uint64 {
+	now := uint64(time.Now().Unix())
+	if len(mem.blocks) != 0 {
+		if last := mem.blocks[len(mem.blocks)-1].header.Time; now <= last {
+			return last + 1
+		}
+	}
+	return now
+}
+
+// Append block, mutex is held by caller.
+func (mem *MemoryRegistry) mine(tx *types.Transaction, receipt *types.Receipt) {
+	header := &types.Header{
+		Number: big.NewInt(int64(len(mem.blocks))),
+		Time: mem.blockTime(),
+		Difficulty: big.NewInt(0),
+		GasLimit: 30000000,
+	}
+	if len(mem.blocks) != 0 {
+		header.ParentHash = mem.blocks[len(mem.blocks)-1].header.Hash()
+	}
+	block := &memoryBlock{header: header}
+	if tx != nil {
+		header.TxHash = tx.Hash()
+		header.GasUsed = receipt.GasUsed
+		receipt.TxHash = tx.Hash()
+		receipt.BlockNumber = header.Number
+		receipt.BlockHash = header.Hash()
+		receipt.CumulativeGasUsed = receipt.GasUsed
+		for i, log := range receipt.Logs {
+			log.BlockNumber = header.Number.Uint64()
+			log.BlockHash = receipt.BlockHash
+			log.TxHash = receipt.TxHash
+			log.Index = uint(i)
+		}
+		block.tx = tx
+		block.receipt = receipt
+		mem.txs[tx.Hash()] = block
+	}
+	mem.blocks = append(mem.blocks, block)
+}
+
+// Check nonce and funds of transaction and mine it. Reverted call is
+// mined with failed receipt and changes only nonce and paid gas.
+func (mem *MemoryRegistry) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	// Transactions signed for another chain are refused like by node.
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(MEMORY_CHAIN_ID)), tx)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+	switch nonce := mem.nonces[from]; {
+	case tx.Nonce() < nonce:
+		return fmt.Errorf("nonce too low: address %s, tx: %d state: %d", from.Hex(), tx.Nonce(), nonce)
+	case tx.Nonce() > nonce:
+		return fmt.Errorf("nonce too high: address %s, tx: %d state: %d", from.Hex(), tx.Nonce(), nonce)
+	}
+	if tx.To() == nil {
+		return errors.New("memory backend can not create contracts")
+	}
+	if mem.state.balance(from).Cmp(tx.Cost()) < 0 {
+		return fmt.Errorf("insufficient funds for gas * price + value: address %s", from.Hex())
+	}
+	gasUsed := uint64(MEMORY_TRANSFER_GAS)
+	if *tx.To() == mem.address {
+		gasUsed = MEMORY_GAS
+	}
+	receipt := &types.Receipt{
+		Type: tx.Type(),
+		GasUsed: gasUsed,
+		Status: types.ReceiptStatusSuccessful,
+	}
+	state := mem.state.copy()
+	state.add(from, new(big.Int).Neg(tx.Value()))
+	state.add(*tx.To(), tx.Value())
+	if gasUsed > tx.Gas() {
+		receipt.GasUsed = tx.Gas()
+		receipt.Status = types.ReceiptStatusFailed
+	} else if *tx.To() == mem.address {
+		call := mem.newCall(state, from, tx.Value())
+		if _, err := call.run(tx.Data()); err != nil {
+			receipt.Status = types.ReceiptStatusFailed
+		}
+		receipt.Logs = call.logs
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		state = mem.state.copy()
+		receipt.Logs = nil
+	}
+	fee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(receipt.GasUsed))
+	state.add(from, fee.Neg(fee))
+	mem.state = state
+	mem.nonces[from]++
+	mem.mine(tx, receipt)
+	return nil
+}
+
+func (mem *MemoryRegistry) newCall(state *memoryState, from common.Address, value *big.Int) *memoryCall {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return &memoryCall{
+		abi: &mem.abi,
+		address: mem.address,
+		state: state,
+		admin: mem.admin,
+		from: from,
+		value: value,
+		now: new(big.Int).SetUint64(mem.blockTime()),
+	}
+}
+
+// Run call on a copy of state in the next block, like eth_call.
+func (mem *MemoryRegistry) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	if msg.To == nil || *msg.To != mem.address {
+		return nil, nil
+	}
+	state := mem.state.copy()
+	if msg.Value != nil && msg.Value.Sign() != 0 {
+		if state.balance(msg.From).Cmp(msg.Value) < 0 {
+			return nil, fmt.Errorf("insufficient funds for gas * price + value: address %s", msg.From.Hex())
+		}
+		state.add(msg.From, new(big.Int).Neg(msg.Value))
+		state.add(mem.address, msg.Value)
+	}
+	return mem.newCall(state, msg.From, msg.Value).run(msg.Data)
+}
+
+func (mem *MemoryRegistry) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if msg.To == nil || *msg.To != mem.address {
+		return MEMORY_TRANSFER_GAS, nil
+	}
+	if _, err := mem.CallContract(ctx, msg, nil); err != nil {
+		return 0, err
+	}
+	return MEMORY_GAS, nil
+}
+
+func (mem *MemoryRegistry) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if account != mem.address {
+		return nil, nil
+	}
+	return common.FromHex(contract.ContractBin), nil
+}
+
+func (mem *MemoryRegistry) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return mem.CodeAt(ctx, account, nil)
+}
+
+func (mem *MemoryRegistry) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return mem.nonces[account], nil
+}
+
+func (mem *MemoryRegistry) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return new(big.Int).Set(mem.state.balance(account)), nil
+}
+
+// Gas is free, blocks have no base fee, so legacy fees are used.
+func (mem *MemoryRegistry) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (mem *MemoryRegistry) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (mem *MemoryRegistry) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(MEMORY_CHAIN_ID), nil
+}
+
+func (mem *MemoryRegistry) NetworkID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(MEMORY_NETWORK_ID), nil
+}
+
+func (mem *MemoryRegistry) BlockNumber(ctx context.Context) (uint64, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return uint64(len(mem.blocks) - 1), nil
+}
+
+func (mem *MemoryRegistry) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	if number == nil {
+		return types.CopyHeader(mem.blocks[len(mem.blocks)-1].header), nil
+	}
+	if !number.IsInt64() || number.Int64() < 0 || number.Int64() >= int64(len(mem.blocks)) {
+		return nil, ethereum.NotFound
+	}
+	return types.CopyHeader(mem.blocks[number.Int64()].header), nil
+}
+
+// Transactions are mined at once, so they are never pending.
+func (mem *MemoryRegistry) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	block, ok := mem.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return block.tx, false, nil
+}
+
+func (mem *MemoryRegistry) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	block, ok := mem.txs[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return block.receipt, nil
+}
+
+// Logs of blocks in range of query matching its addresses and topics.
+func (mem *MemoryRegistry) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	from, to := uint64(0), uint64(len(mem.blocks)-1)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Uint64()
+	}
+	if query.ToBlock != nil && query.ToBlock.Uint64() < to {
+		to = query.ToBlock.Uint64()
+	}
+	var logs []types.Log
+	for number := from; number <= to && number < uint64(len(mem.blocks)); number++ {
+		block := mem.blocks[number]
+		if block.receipt == nil {
+			continue
+		}
+		if query.BlockHash != nil && *query.BlockHash != block.header.Hash() {
+			continue
+		}
+		for _, log := range block.receipt.Logs {
+			if matchLog(log, query) {
+				logs = append(logs, *log)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matchLog(log *types.Log, query ethereum.FilterQuery) bool {
+	if len(query.Addresses) != 0 {
+		found := false
+		for _, address := range query.Addresses {
+			if address == log.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for i, topics := range query.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) {
+			return false
+		}
+		found := false
+		for _, topic := range topics {
+			if topic == log.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (mem *MemoryRegistry) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("memory backend does not support subscriptions, events are polled")
+}
+
+var _ ChainBackend = (*MemoryRegistry)(nil)
@@ -0,0 +1,781 @@
+package worldskills
+
+import (
+	"fmt"
+	"sync"
+	"errors"
+	"context"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+const (
+	// Address of contract in memory blocks.
+	MEMORY_CONTRACT = "0x0000000000000000000000000000000000005757"
+	// Every account starts with 100 ether, like Ganache accounts.
+	MEMORY_BALANCE = "100000000000000000000"
+	// Gas used by every call of contract and by transfers.
+	MEMORY_GAS = 100000
+	MEMORY_TRANSFER_GAS = 21000
+	// Network and chain id of memory blocks, the ones of Ganache.
+	MEMORY_NETWORK_ID = 5777
+	MEMORY_CHAIN_ID = 1337
+)
+
+// WorldSkills contract emulated in memory, with the same modifiers, require
+// statements, transfers and events as contract.sol. It is a Registry and a
+// ChainBackend which mines every transaction at once into its own block.
+// Accounts have fake balances of MEMORY_BALANCE, gas is free.
+type MemoryRegistry struct {
+	mutex sync.Mutex
+	abi abi.ABI
+	address common.Address
+	admin common.Address
+	state *memoryState
+	nonces map[common.Address]uint64
+	blocks []*memoryBlock
+	txs map[common.Hash]*memoryBlock
+}
+
+// Records and balances, copied before every call and kept only
+// if the call succeeds, as EVM reverts all changes of failed call.
+type memoryState struct {
+	estates []*Estate
+	presents []*Present
+	sales []*Sale
+	rents []*Rent
+	balances map[common.Address]*big.Int
+}
+
+// Admin is the deployer of contract.
+func NewMemoryRegistry(admin common.Address) (*MemoryRegistry, error) {
+	parsed, err := contractABI()
+	if err != nil {
+		return nil, err
+	}
+	address := common.HexToAddress(MEMORY_CONTRACT)
+	mem := &MemoryRegistry{
+		abi: parsed,
+		address: address,
+		admin: admin,
+		state: &memoryState{
+			balances: map[common.Address]*big.Int{
+				address: big.NewInt(0),
+			},
+		},
+		nonces: make(map[common.Address]uint64),
+		txs: make(map[common.Hash]*memoryBlock),
+	}
+	mem.mine(nil, nil)
+	return mem, nil
+}
+
+func (mem *MemoryRegistry) IamAdmin(user *UserType) (bool, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return user.AddressEth == mem.admin, nil
+}
+
+func (mem *MemoryRegistry) EstatesNumber(user *UserType) (*big.Int, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return big.NewInt(int64(len(mem.state.estates))), nil
+}
+
+func (mem *MemoryRegistry) PresentsNumber(user *UserType) (*big.Int, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return big.NewInt(int64(len(mem.state.presents))), nil
+}
+
+func (mem *MemoryRegistry) SalesNumber(user *UserType) (*big.Int, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return big.NewInt(int64(len(mem.state.sales))), nil
+}
+
+func (mem *MemoryRegistry) RentsNumber(user *UserType) (*big.Int, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	return big.NewInt(int64(len(mem.state.rents))), nil
+}
+
+// Getters return copies, so callers can not change records.
+func (mem *MemoryRegistry) GetEstates(user *UserType, index *big.Int) (*Estate, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	estate, err := mem.state.estate(index)
+	if err != nil {
+		return nil, fmt.Errorf("get estate %s: %w", index, err)
+	}
+	return copyEstate(estate), nil
+}
+
+func (mem *MemoryRegistry) GetPresents(user *UserType, index *big.Int) (*Present, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	present, err := mem.state.present(index)
+	if err != nil {
+		return nil, fmt.Errorf("get present %s: %w", index, err)
+	}
+	return copyPresent(present), nil
+}
+
+func (mem *MemoryRegistry) GetSales(user *UserType, index *big.Int) (*Sale, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	sale, err := mem.state.sale(index)
+	if err != nil {
+		return nil, fmt.Errorf("get sale %s: %w", index, err)
+	}
+	return copySale(sale), nil
+}
+
+func (mem *MemoryRegistry) GetRents(user *UserType, index *big.Int) (*Rent, error) {
+	mem.mutex.Lock()
+	defer mem.mutex.Unlock()
+	rent, err := mem.state.rent(index)
+	if err != nil {
+		return nil, fmt.Errorf("get rent %s: %w", index, err)
+	}
+	return copyRent(rent), nil
+}
+
+func (mem *MemoryRegistry) CreateEstate(auth *bind.TransactOpts, owner common.Address, info string, squere *big.Int, usefulSquere *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "create_estate", owner, info, squere, usefulSquere)
+}
+
+func (mem *MemoryRegistry) CreatePresent(auth *bind.TransactOpts, estateId *big.Int, addressTo common.Address) (*types.Transaction, error) {
+	return mem.transact(auth, "create_present", estateId, addressTo)
+}
+
+func (mem *MemoryRegistry) CancelPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "cancel_present", presentId)
+}
+
+func (mem *MemoryRegistry) ConfirmPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "confirm_present", presentId)
+}
+
+func (mem *MemoryRegistry) CreateSale(auth *bind.TransactOpts, estateId *big.Int, price *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "create_sale", estateId, price)
+}
+
+func (mem *MemoryRegistry) CancelSale(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "cancel_sale", saleId)
+}
+
+func (mem *MemoryRegistry) CheckToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "check_to_buy", saleId)
+}
+
+func (mem *MemoryRegistry) CancelToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "cancel_to_buy", saleId)
+}
+
+func (mem *MemoryRegistry) ConfirmSale(auth *bind.TransactOpts, saleId *big.Int, saleTo *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "confirm_sale", saleId, saleTo)
+}
+
+func (mem *MemoryRegistry) CreateRent(auth *bind.TransactOpts, estateId *big.Int, days *big.Int, money *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "create_rent", estateId, days, money)
+}
+
+func (mem *MemoryRegistry) ToRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "to_rent", rentId)
+}
+
+func (mem *MemoryRegistry) CancelRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "cancel_rent", rentId)
+}
+
+func (mem *MemoryRegistry) FinishRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return mem.transact(auth, "finish_rent", rentId)
+}
+
+// Sign call of method like abigen bindings do and mine it. Like on chain
+// a reverted call is mined with failed receipt and only refused
+// transactions (nonce, funds) return error.
+func (mem *MemoryRegistry) transact(auth *bind.TransactOpts, method string, args ...interface{}) (*types.Transaction, error) {
+	input, err := mem.abi.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", method, err)
+	}
+	if auth.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	var nonce uint64
+	if auth.Nonce != nil {
+		nonce = auth.Nonce.Uint64()
+	} else if nonce, err = mem.PendingNonceAt(context.Background(), auth.From); err != nil {
+		return nil, err
+	}
+	gasLimit := auth.GasLimit
+	if gasLimit == 0 {
+		gasLimit = MEMORY_GAS
+	}
+	gasPrice := auth.GasPrice
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
+	}
+	tx, err := auth.Signer(auth.From, types.NewTransaction(nonce, mem.address, txValue(auth), gasLimit, gasPrice, input))
+	if err != nil {
+		return nil, err
+	}
+	if err := mem.SendTransaction(context.Background(), tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func txValue(auth *bind.TransactOpts) *big.Int {
+	if auth.Value == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(auth.Value)
+}
+
+// Call of contract from one sender in one block.
+type memoryCall struct {
+	abi *abi.ABI
+	address common.Address
+	state *memoryState
+	admin common.Address
+	from common.Address
+	value *big.Int
+	now *big.Int
+	logs []*types.Log
+}
+
+func revert(format string, args ...interface{}) error {
+	return fmt.Errorf("execution reverted: "+format, args...)
+}
+
+// Run input of transaction or eth_call, getters return packed outputs.
+func (call *memoryCall) run(input []byte) ([]byte, error) {
+	if len(input) < 4 {
+		return nil, revert("no method")
+	}
+	method, err := call.abi.MethodById(input[:4])
+	if err != nil {
+		return nil, revert("unknown method %x", input[:4])
+	}
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, revert("unpack %s: %s", method.RawName, err)
+	}
+	if !method.IsPayable() && call.value.Sign() != 0 {
+		return nil, revert("%s is not payable", method.RawName)
+	}
+	state := call.state
+	switch method.RawName {
+	case "iam_admin":
+		return method.Outputs.Pack(call.from == call.admin)
+	case "get_estates_number":
+		return method.Outputs.Pack(big.NewInt(int64(len(state.estates))))
+	case "get_presents_number":
+		return method.Outputs.Pack(big.NewInt(int64(len(state.presents))))
+	case "get_sales_number":
+		return method.Outputs.Pack(big.NewInt(int64(len(state.sales))))
+	case "get_rents_number":
+		return method.Outputs.Pack(big.NewInt(int64(len(state.rents))))
+	case "get_estates", "get_estates_statuses":
+		estate, err := state.estate(args[0].(*big.Int))
+		if err != nil {
+			return nil, err
+		}
+		if method.RawName == "get_estates_statuses" {
+			return method.Outputs.Pack(estate.PresentStatus, estate.SaleStatus, estate.RentStatus)
+		}
+		return method.Outputs.Pack(estate.Id, estate.Owner, estate.Info, estate.Squere, estate.UsefulSquere, estate.RenterAddress)
+	case "get_presents":
+		present, err := state.present(args[0].(*big.Int))
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(present.EstateId, present.AddressFrom, present.AddressTo, present.Finished)
+	case "get_sales":
+		sale, err := state.sale(args[0].(*big.Int))
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(sale.EstateId, sale.Owner, sale.Price, sale.Customers, sale.Prices, sale.Finished)
+	case "get_rents":
+		rent, err := state.rent(args[0].(*big.Int))
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(rent.EstateId, rent.OwnerAddress, rent.RenterAddress, rent.Time, rent.Money, rent.Deadline, rent.Finished)
+	case "create_estate":
+		err = call.createEstate(args[0].(common.Address), args[1].(string), args[2].(*big.Int), args[3].(*big.Int))
+	case "create_present":
+		err = call.createPresent(args[0].(*big.Int), args[1].(common.Address))
+	case "cancel_present":
+		err = call.cancelPresent(args[0].(*big.Int))
+	case "confirm_present":
+		err = call.confirmPresent(args[0].(*big.Int))
+	case "create_sale":
+		err = call.createSale(args[0].(*big.Int), args[1].(*big.Int))
+	case "cancel_sale":
+		err = call.cancelSale(args[0].(*big.Int))
+	case "check_to_buy":
+		err = call.checkToBuy(args[0].(*big.Int))
+	case "cancel_to_buy":
+		err = call.cancelToBuy(args[0].(*big.Int))
+	case "confirm_sale":
+		err = call.confirmSale(args[0].(*big.Int), args[1].(*big.Int))
+	case "create_rent":
+		err = call.createRent(args[0].(*big.Int), args[1].(*big.Int), args[2].(*big.Int))
+	case "to_rent":
+		err = call.toRent(args[0].(*big.Int))
+	case "cancel_rent":
+		err = call.cancelRent(args[0].(*big.Int))
+	case "finish_rent":
+		err = call.finishRent(args[0].(*big.Int))
+	default:
+		return nil, revert("unknown method %s", method.RawName)
+	}
+	return nil, err
+}
+
+// Modifiers of contract.sol.
+func (call *memoryCall) isAdmin() error {
+	if call.from != call.admin {
+		return revert("only admin")
+	}
+	return nil
+}
+
+func (call *memoryCall) isOwner(estateId *big.Int) error {
+	estate, err := call.state.estate(estateId)
+	if err != nil {
+		return err
+	}
+	if call.from != estate.Owner {
+		return revert("only owner of estate %s", estateId)
+	}
+	return nil
+}
+
+func (call *memoryCall) statusOK(estateId *big.Int) error {
+	estate, err := call.state.estate(estateId)
+	if err != nil {
+		return err
+	}
+	if estate.PresentStatus || estate.SaleStatus || estate.RentStatus {
+		return revert("estate %s is presented, on sale or in rent", estateId)
+	}
+	return nil
+}
+
+func (call *memoryCall) createEstate(owner common.Address, info string, squere *big.Int, usefulSquere *big.Int) error {
+	if err := call.isAdmin(); err != nil {
+		return err
+	}
+	id := big.NewInt(int64(len(call.state.estates)))
+	call.state.estates = append(call.state.estates, &Estate{
+		Id: id,
+		Owner: owner,
+		Info: info,
+		Squere: squere,
+		UsefulSquere: usefulSquere,
+	})
+	return call.emit("EstateCreated", []common.Hash{common.BigToHash(id), owner.Hash()})
+}
+
+func (call *memoryCall) createPresent(estateId *big.Int, addressTo common.Address) error {
+	if err := call.statusOK(estateId); err != nil {
+		return err
+	}
+	if err := call.isOwner(estateId); err != nil {
+		return err
+	}
+	id := big.NewInt(int64(len(call.state.presents)))
+	call.state.presents = append(call.state.presents, &Present{
+		Id: id,
+		EstateId: estateId,
+		AddressFrom: call.from,
+		AddressTo: addressTo,
+	})
+	call.state.estates[estateId.Int64()].PresentStatus = true
+	return call.emitRecord("PresentCreated", id, estateId, call.from, addressTo)
+}
+
+func (call *memoryCall) cancelPresent(presentId *big.Int) error {
+	present, err := call.state.present(presentId)
+	if err != nil {
+		return err
+	}
+	if call.from != present.AddressFrom {
+		return revert("only sender of present")
+	}
+	if present.Finished {
+		return revert("present is finished")
+	}
+	call.state.estates[present.EstateId.Int64()].PresentStatus = false
+	present.Finished = true
+	return call.emitRecord("PresentCancelled", presentId, present.EstateId)
+}
+
+func (call *memoryCall) confirmPresent(presentId *big.Int) error {
+	present, err := call.state.present(presentId)
+	if err != nil {
+		return err
+	}
+	if call.from != present.AddressTo {
+		return revert("only recipient of present")
+	}
+	if present.Finished {
+		return revert("present is finished")
+	}
+	estate := call.state.estates[present.EstateId.Int64()]
+	estate.Owner = present.AddressTo
+	estate.PresentStatus = false
+	present.Finished = true
+	return call.emitRecord("PresentConfirmed", presentId, present.EstateId, present.AddressFrom, call.from)
+}
+
+func (call *memoryCall) createSale(estateId *big.Int, price *big.Int) error {
+	if err := call.statusOK(estateId); err != nil {
+		return err
+	}
+	if err := call.isOwner(estateId); err != nil {
+		return err
+	}
+	id := big.NewInt(int64(len(call.state.sales)))
+	call.state.sales = append(call.state.sales, &Sale{
+		Id: id,
+		EstateId: estateId,
+		Owner: call.from,
+		Price: price,
+	})
+	call.state.estates[estateId.Int64()].SaleStatus = true
+	return call.emitRecord("SaleCreated", id, estateId, call.from, price)
+}
+
+// Every bid, withdrawn bids are zero, is sent back to its customer.
+func (call *memoryCall) cancelSale(saleId *big.Int) error {
+	sale, err := call.state.sale(saleId)
+	if err != nil {
+		return err
+	}
+	if call.from != sale.Owner {
+		return revert("only seller")
+	}
+	if sale.Finished {
+		return revert("sale is finished")
+	}
+	for i, customer := range sale.Customers {
+		if err := call.transfer(customer, sale.Prices[i]); err != nil {
+			return err
+		}
+	}
+	call.state.estates[sale.EstateId.Int64()].SaleStatus = false
+	sale.Finished = true
+	return call.emitRecord("SaleCancelled", saleId, sale.EstateId)
+}
+
+func (call *memoryCall) checkToBuy(saleId *big.Int) error {
+	sale, err := call.state.sale(saleId)
+	if err != nil {
+		return err
+	}
+	if call.from == sale.Owner {
+		return revert("seller can not bid")
+	}
+	if call.value.Cmp(sale.Price) < 0 {
+		return revert("bid is lower than price")
+	}
+	if sale.Finished {
+		return revert("sale is finished")
+	}
+	for _, customer := range sale.Customers {
+		if customer == call.from {
+			return revert("customer already bid")
+		}
+	}
+	sale.Customers = append(sale.Customers, call.from)
+	sale.Prices = append(sale.Prices, call.value)
+	return call.emitRecord("BidPlaced", saleId, sale.EstateId, call.from, call.value)
+}
+
+// Customer stays in the list with zero bid and can not bid again.
+// BidWithdrawn is emitted only if there was a bid to withdraw.
+func (call *memoryCall) cancelToBuy(saleId *big.Int) error {
+	sale, err := call.state.sale(saleId)
+	if err != nil {
+		return err
+	}
+	if sale.Finished {
+		return revert("sale is finished")
+	}
+	withdrawn := false
+	for i, customer := range sale.Customers {
+		if customer != call.from || sale.Prices[i].Sign() == 0 {
+			continue
+		}
+		if err := call.transfer(call.from, sale.Prices[i]); err != nil {
+			return err
+		}
+		sale.Prices[i] = big.NewInt(0)
+		withdrawn = true
+	}
+	if !withdrawn {
+		return nil
+	}
+	return call.emitRecord("BidWithdrawn", saleId, sale.EstateId, call.from)
+}
+
+// Seller gets the chosen bid, other bids are sent back.
+func (call *memoryCall) confirmSale(saleId *big.Int, saleTo *big.Int) error {
+	sale, err := call.state.sale(saleId)
+	if err != nil {
+		return err
+	}
+	if call.from != sale.Owner {
+		return revert("only seller")
+	}
+	if !saleTo.IsInt64() || saleTo.Int64() < 0 || saleTo.Int64() >= int64(len(sale.Prices)) {
+		return revert("sale %s has no customer %s", saleId, saleTo)
+	}
+	to := saleTo.Int64()
+	if sale.Prices[to].Sign() == 0 {
+		return revert("bid is withdrawn")
+	}
+	if sale.Finished {
+		return revert("sale is finished")
+	}
+	price, customer := sale.Prices[to], sale.Customers[to]
+	call.state.estates[sale.EstateId.Int64()].Owner = customer
+	if err := call.transfer(call.from, price); err != nil {
+		return err
+	}
+	for i, customer := range sale.Customers {
+		if int64(i) == to {
+			sale.Prices[i] = big.NewInt(0)
+			continue
+		}
+		if err := call.transfer(customer, sale.Prices[i]); err != nil {
+			return err
+		}
+	}
+	call.state.estates[sale.EstateId.Int64()].SaleStatus = false
+	sale.Finished = true
+	return call.emitRecord("SaleConfirmed", saleId, sale.EstateId, call.from, customer, price)
+}
+
+func (call *memoryCall) createRent(estateId *big.Int, days *big.Int, money *big.Int) error {
+	if err := call.isOwner(estateId); err != nil {
+		return err
+	}
+	if err := call.statusOK(estateId); err != nil {
+		return err
+	}
+	id := big.NewInt(int64(len(call.state.rents)))
+	call.state.rents = append(call.state.rents, &Rent{
+		Id: id,
+		EstateId: estateId,
+		OwnerAddress: call.from,
+		Time: days,
+		Money: money,
+		Deadline: big.NewInt(0),
+	})
+	call.state.estates[estateId.Int64()].RentStatus = true
+	return call.emitRecord("RentCreated", id, estateId, call.from, days, money)
+}
+
+// Renter pays owner at once, deadline is block time plus days of rent.
+func (call *memoryCall) toRent(rentId *big.Int) error {
+	rent, err := call.state.rent(rentId)
+	if err != nil {
+		return err
+	}
+	if rent.Finished {
+		return revert("rent is finished")
+	}
+	if rent.RenterAddress != (common.Address{}) {
+		return revert("rent is taken")
+	}
+	if rent.OwnerAddress == call.from {
+		return revert("owner can not take rent")
+	}
+	if rent.Money.Cmp(call.value) != 0 {
+		return revert("value is not money of rent")
+	}
+	rent.RenterAddress = call.from
+	call.state.estates[rent.EstateId.Int64()].RenterAddress = call.from
+	rent.Deadline = new(big.Int).Add(call.now, new(big.Int).Mul(rent.Time, big.NewInt(86400)))
+	if err := call.transfer(rent.OwnerAddress, rent.Money); err != nil {
+		return err
+	}
+	return call.emitRecord("RentTaken", rentId, rent.EstateId, call.from, call.value)
+}
+
+func (call *memoryCall) cancelRent(rentId *big.Int) error {
+	rent, err := call.state.rent(rentId)
+	if err != nil {
+		return err
+	}
+	if rent.Finished {
+		return revert("rent is finished")
+	}
+	if rent.OwnerAddress != call.from {
+		return revert("only owner of rent")
+	}
+	if rent.RenterAddress != (common.Address{}) {
+		return revert("rent is taken")
+	}
+	call.state.estates[rent.EstateId.Int64()].RentStatus = false
+	rent.Finished = true
+	return call.emitRecord("RentCancelled", rentId, rent.EstateId)
+}
+
+// Rent which was not taken has zero deadline and can be finished at once.
+func (call *memoryCall) finishRent(rentId *big.Int) error {
+	rent, err := call.state.rent(rentId)
+	if err != nil {
+		return err
+	}
+	if err := call.isOwner(rent.EstateId); err != nil {
+		return err
+	}
+	if rent.Finished {
+		return revert("rent is finished")
+	}
+	if rent.Deadline.Cmp(call.now) >= 0 {
+		return revert("deadline of rent is not passed")
+	}
+	estate := call.state.estates[rent.EstateId.Int64()]
+	estate.RenterAddress = common.Address{}
+	estate.RentStatus = false
+	rent.Finished = true
+	return call.emitRecord("RentFinished", rentId, rent.EstateId, rent.RenterAddress)
+}
+
+// Send wei from contract, like address.transfer.
+func (call *memoryCall) transfer(to common.Address, amount *big.Int) error {
+	if call.state.balance(call.address).Cmp(amount) < 0 {
+		return revert("contract balance is too low")
+	}
+	call.state.add(call.address, new(big.Int).Neg(amount))
+	call.state.add(to, amount)
+	return nil
+}
+
+// Record events have id and estate_id as indexed arguments.
+func (call *memoryCall) emitRecord(name string, id *big.Int, estateId *big.Int, data ...interface{}) error {
+	return call.emit(name, []common.Hash{common.BigToHash(id), common.BigToHash(estateId)}, data...)
+}
+
+func (call *memoryCall) emit(name string, topics []common.Hash, data ...interface{}) error {
+	event, ok := call.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("unknown event %s", name)
+	}
+	packed, err := event.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		return fmt.Errorf("pack %s: %w", name, err)
+	}
+	call.logs = append(call.logs, &types.Log{
+		Address: call.address,
+		Topics: append([]common.Hash{event.ID}, topics...),
+		Data: packed,
+	})
+	return nil
+}
+
+// Arrays of contract revert on index out of range.
+func (state *memoryState) estate(index *big.Int) (*Estate, error) {
+	if !index.IsInt64() || index.Int64() < 0 || index.Int64() >= int64(len(state.estates)) {
+		return nil, revert("estate %s does not exist", index)
+	}
+	return state.estates[index.Int64()], nil
+}
+
+func (state *memoryState) present(index *big.Int) (*Present, error) {
+	if !index.IsInt64() || index.Int64() < 0 || index.Int64() >= int64(len(state.presents)) {
+		return nil, revert("present %s does not exist", index)
+	}
+	return state.presents[index.Int64()], nil
+}
+
+func (state *memoryState) sale(index *big.Int) (*Sale, error) {
+	if !index.IsInt64() || index.Int64() < 0 || index.Int64() >= int64(len(state.sales)) {
+		return nil, revert("sale %s does not exist", index)
+	}
+	return state.sales[index.Int64()], nil
+}
+
+func (state *memoryState) rent(index *big.Int) (*Rent, error) {
+	if !index.IsInt64() || index.Int64() < 0 || index.Int64() >= int64(len(state.rents)) {
+		return nil, revert("rent %s does not exist", index)
+	}
+	return state.rents[index.Int64()], nil
+}
+
+// Accounts which were never seen have MEMORY_BALANCE.
+func (state *memoryState) balance(address common.Address) *big.Int {
+	if balance, ok := state.balances[address]; ok {
+		return balance
+	}
+	balance, _ := new(big.Int).SetString(MEMORY_BALANCE, 10)
+	return balance
+}
+
+func (state *memoryState) add(address common.Address, amount *big.Int) {
+	state.balances[address] = new(big.Int).Add(state.balance(address), amount)
+}
+
+// Big numbers are never changed in place, so they are shared by copies.
+func (state *memoryState) copy() *memoryState {
+	copied := &memoryState{
+		estates: make([]*Estate, len(state.estates)),
+		presents: make([]*Present, len(state.presents)),
+		sales: make([]*Sale, len(state.sales)),
+		rents: make([]*Rent, len(state.rents)),
+		balances: make(map[common.Address]*big.Int, len(state.balances)),
+	}
+	for i, estate := range state.estates {
+		copied.estates[i] = copyEstate(estate)
+	}
+	for i, present := range state.presents {
+		copied.presents[i] = copyPresent(present)
+	}
+	for i, sale := range state.sales {
+		copied.sales[i] = copySale(sale)
+	}
+	for i, rent := range state.rents {
+		copied.rents[i] = copyRent(rent)
+	}
+	for address, balance := range state.balances {
+		copied.balances[address] = balance
+	}
+	return copied
+}
+
+func copyEstate(estate *Estate) *Estate {
+	copied := *estate
+	return &copied
+}
+
+func copyPresent(present *Present) *Present {
+	copied := *present
+	return &copied
+}
+
+func copySale(sale *Sale) *Sale {
+	copied := *sale
+	copied.Customers = append([]common.Address(nil), sale.Customers...)
+	copied.Prices = append([]*big.Int(nil), sale.Prices...)
+	return &copied
+}
+
+func copyRent(rent *Rent) *Rent {
+	copied := *rent
+	return &copied
+}
+
+var _ Registry = (*MemoryRegistry)(nil)
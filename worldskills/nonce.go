@@ -0,0 +1,238 @@
+package worldskills
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"context"
+	"strings"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Nonces of accounts sending from this process. Nonce is read from node
+// once and then incremented locally, so transactions sent one after another
+// do not take the same pending nonce. Nonces of failed sends are reused.
+type NonceManager struct {
+	mutex sync.Mutex
+	client ChainBackend
+	accounts map[common.Address]*accountNonce
+}
+
+type accountNonce struct {
+	next uint64
+	free []uint64
+}
+
+var (
+	Nonces *NonceManager
+)
+
+func NewNonceManager(client ChainBackend) *NonceManager {
+	return &NonceManager{
+		client: client,
+		accounts: make(map[common.Address]*accountNonce),
+	}
+}
+
+// Lowest released nonce of address or the next one.
+func (nm *NonceManager) Take(address common.Address) (uint64, error) {
+	nm.mutex.Lock()
+	defer nm.mutex.Unlock()
+	account, ok := nm.accounts[address]
+	if !ok {
+		pending, err := nm.client.PendingNonceAt(context.Background(), address)
+		if err != nil {
+			return 0, fmt.Errorf("get nonce: %w", err)
+		}
+		account = &accountNonce{next: pending}
+		nm.accounts[address] = account
+	}
+	if len(account.free) != 0 {
+		sort.Slice(account.free, func(i, j int) bool { return account.free[i] < account.free[j] })
+		nonce := account.free[0]
+		account.free = account.free[1:]
+		return nonce, nil
+	}
+	nonce := account.next
+	account.next++
+	return nonce, nil
+}
+
+// Give back nonce of transaction which was not sent.
+func (nm *NonceManager) Release(address common.Address, nonce uint64) {
+	nm.mutex.Lock()
+	defer nm.mutex.Unlock()
+	account, ok := nm.accounts[address]
+	if !ok || nonce >= account.next {
+		return
+	}
+	if nonce+1 == account.next {
+		account.next--
+		return
+	}
+	account.free = append(account.free, nonce)
+}
+
+// Forget local nonces of address, they are read from node on next Take.
+func (nm *NonceManager) Resync(address common.Address) {
+	nm.mutex.Lock()
+	defer nm.mutex.Unlock()
+	delete(nm.accounts, address)
+}
+
+// Send transaction with nonce from Nonces. Nonce of failed send is released.
+// "nonce too low" means that nonce was used outside of this process, then
+// nonces are read from node again and send is retried once.
+func SendTx(auth *bind.TransactOpts, send func(*bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	for retry := 0; ; retry++ {
+		nonce, err := Nonces.Take(auth.From)
+		if err != nil {
+			return nil, err
+		}
+		auth.Nonce = new(big.Int).SetUint64(nonce)
+		tx, err := send(auth)
+		if err == nil {
+			return tx, nil
+		}
+		if retry == 0 && strings.Contains(strings.ToLower(err.Error()), "nonce too low") {
+			Nonces.Resync(auth.From)
+			continue
+		}
+		Nonces.Release(auth.From, nonce)
+		return nil, err
+	}
+}
+
+// Send pending transaction of user again with the same nonce and fees at
+// least 10% higher, as nodes require to replace it. EIP-1559 transaction
+// is replaced by one with higher priority fee and max fee, legacy one by
+// higher gas price, neither above Config.MaxFee. Cancel sends nothing to
+// user instead of the original call.
+func ReplaceTx(user *UserType, hash common.Hash, cancel bool) (*types.Transaction, error) {
+	tx, pending, err := ClientETH.TransactionByHash(context.Background(), hash)
+	if err != nil {
+		return nil, fmt.Errorf("get tx %s: %w", hash.Hex(), err)
+	}
+	if !pending {
+		return nil, fmt.Errorf("tx %s is already mined", hash.Hex())
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("get sender of tx %s: %w", hash.Hex(), err)
+	}
+	if from != user.AddressEth {
+		return nil, fmt.Errorf("tx %s is sent by %s", hash.Hex(), from.Hex())
+	}
+	to, value, gas, data := tx.To(), tx.Value(), tx.Gas(), tx.Data()
+	if cancel {
+		to, value, gas, data = &user.AddressEth, big.NewInt(0), 21000, nil
+	}
+	var replacement types.TxData
+	if tx.Type() == types.DynamicFeeTxType {
+		tip, feeCap, err := bumpFees(tx)
+		if err != nil {
+			return nil, err
+		}
+		replacement = &types.DynamicFeeTx{
+			ChainID: tx.ChainId(),
+			Nonce: tx.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas: gas,
+			To: to,
+			Value: value,
+			Data: data,
+			AccessList: tx.AccessList(),
+		}
+	} else {
+		gasPrice, err := bumpGasPrice(tx)
+		if err != nil {
+			return nil, err
+		}
+		replacement = &types.LegacyTx{
+			Nonce: tx.Nonce(),
+			GasPrice: gasPrice,
+			Gas: gas,
+			To: to,
+			Value: value,
+			Data: data,
+		}
+	}
+	auth, err := ResetAuth(user)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := auth.Signer(user.AddressEth, types.NewTx(replacement))
+	if err != nil {
+		return nil, fmt.Errorf("sign tx: %w", err)
+	}
+	if err := ClientETH.SendTransaction(context.Background(), signed); err != nil {
+		return nil, fmt.Errorf("send tx: %w", err)
+	}
+	return signed, nil
+}
+
+// Fee raised by 10% and 1 wei, the least nodes take for replacement.
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Div(new(big.Int).Mul(fee, big.NewInt(110)), big.NewInt(100))
+	return bumped.Add(bumped, big.NewInt(1))
+}
+
+// Gas price suggested by node, at least bumped price of tx and at most
+// max fee.
+func bumpGasPrice(tx *types.Transaction) (*big.Int, error) {
+	gasPrice, err := ClientETH.SuggestGasPrice(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("get gas price: %w", err)
+	}
+	bumped := bumpFee(tx.GasPrice())
+	if gasPrice.Cmp(bumped) < 0 {
+		gasPrice = bumped
+	}
+	if maxFee := Config.MaxFeeWei(); maxFee != nil && gasPrice.Cmp(maxFee) > 0 {
+		if bumped.Cmp(maxFee) > 0 {
+			return nil, fmt.Errorf("replacement needs gas price %s wei, above max fee %s wei", bumped, maxFee)
+		}
+		gasPrice = maxFee
+	}
+	return gasPrice, nil
+}
+
+// Priority fee and max fee like setFees, at least bumped fees of tx.
+func bumpFees(tx *types.Transaction) (*big.Int, *big.Int, error) {
+	tip := Config.PriorityFeeWei()
+	if tip == nil {
+		var err error
+		tip, err = ClientETH.SuggestGasTipCap(context.Background())
+		if err != nil {
+			return nil, nil, fmt.Errorf("get priority fee: %w", err)
+		}
+	}
+	if bumped := bumpFee(tx.GasTipCap()); tip.Cmp(bumped) < 0 {
+		tip = bumped
+	}
+	bumped := bumpFee(tx.GasFeeCap())
+	feeCap := Config.MaxFeeWei()
+	if feeCap == nil {
+		header, err := ClientETH.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get latest block: %w", err)
+		}
+		feeCap = bumped
+		if header.BaseFee != nil {
+			if fee := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip); feeCap.Cmp(fee) < 0 {
+				feeCap = fee
+			}
+		}
+	} else if feeCap.Cmp(bumped) < 0 {
+		return nil, nil, fmt.Errorf("replacement needs max fee %s wei, above max fee %s wei", bumped, feeCap)
+	}
+	// Suggested priority fee can be above max fee, bumped one can not.
+	if tip.Cmp(feeCap) > 0 {
+		tip = feeCap
+	}
+	return tip, feeCap, nil
+}
@@ -0,0 +1,63 @@
+package worldskills_test
+
+import (
+	"testing"
+	"math/big"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+// Pending tx is replaced by one with the same type of fees, which node
+// takes, and never above max fee.
+func TestReplaceTx(t *testing.T) {
+	for _, fees := range []string{"legacy", "eip1559"} {
+		t.Run(fees, func(t *testing.T) {
+			users := chaintest.Setup(t, "contract", "-fees:"+fees)
+			chain := worldskills.ClientETH.(*chaintest.ChainType)
+			chain.Hold()
+			args := []interface{}{users.First.AddressEth, "flat", big.NewInt(100), big.NewInt(80)}
+			auth, err := worldskills.ResetAuth(users.Admin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := worldskills.Preflight(users.Admin, auth, "create_estate", args...); err != nil {
+				t.Fatal(err)
+			}
+			tx, err := worldskills.SendTx(auth, func(auth *bind.TransactOpts) (*types.Transaction, error) {
+				return worldskills.Backend.CreateEstate(auth, users.First.AddressEth, "flat", big.NewInt(100), big.NewInt(80))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			worldskills.Config.MaxFee = tx.GasFeeCap().String()
+			if _, err := worldskills.ReplaceTx(users.Admin, tx.Hash(), false); err == nil {
+				t.Fatalf("tx is replaced with fees above max fee %s wei", worldskills.Config.MaxFee)
+			}
+			maxFee := new(big.Int).Mul(tx.GasFeeCap(), big.NewInt(2))
+			worldskills.Config.MaxFee = maxFee.String()
+			replacement, err := worldskills.ReplaceTx(users.Admin, tx.Hash(), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replacement.Type() != tx.Type() || replacement.GasFeeCap().Cmp(maxFee) > 0 {
+				t.Fatalf("tx of type %d with max fee %s wei replaced by type %d with %s wei", tx.Type(), maxFee, replacement.Type(), replacement.GasFeeCap())
+			}
+
+			chain.Release(t)
+			receipt, err := worldskills.WaitTx(replacement)
+			if err != nil {
+				t.Fatal(err)
+			}
+			number, err := worldskills.Backend.EstatesNumber(users.Admin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !receipt.Success || number.Int64() != 1 {
+				t.Fatalf("replacement mined with success %v, %s estates, want 1", receipt.Success, number)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package worldskills
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"strings"
+	"math/big"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// Error of Preflight when contract refuses the call. Other errors of
+// Preflight come from node, gas estimation or fees.
+type RefusedError struct {
+	Err error
+}
+
+func (e *RefusedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RefusedError) Unwrap() error {
+	return e.Err
+}
+
+// Simulate method of contract with eth_call from user before sending it.
+// Reverted call is explained by checking the same state as modifiers and
+// require statements of contract.sol, and returned as RefusedError.
+// Successful call sets gas of auth.
+func Preflight(user *UserType, auth *bind.TransactOpts, method string, args ...interface{}) error {
+	parsed, err := contractABI()
+	if err != nil {
+		return err
+	}
+	input, err := parsed.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("pack %s: %w", method, err)
+	}
+	_, err = ClientETH.CallContract(context.Background(), ethereum.CallMsg{
+		From: user.AddressEth,
+		To: &ContractAddr,
+		Value: auth.Value,
+		Data: input,
+	}, nil)
+	if err == nil {
+		return SetGas(auth, method, &ContractAddr, input)
+	}
+	if !strings.Contains(err.Error(), "execution reverted") {
+		return fmt.Errorf("call %s: %w", method, err)
+	}
+	if reason := explain(user, auth.Value, method, args...); reason != "" {
+		return &RefusedError{errors.New(reason)}
+	}
+	return &RefusedError{fmt.Errorf("%s would fail: %w", method, err)}
+}
+
+func explain(user *UserType, value *big.Int, method string, args ...interface{}) string {
+	// Auth without value sends none.
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	switch method {
+	case "create_estate":
+		return explainAdmin(user)
+	case "create_present", "create_sale", "create_rent":
+		return explainEstate(user, args[0].(*big.Int))
+	case "cancel_present", "confirm_present":
+		return explainPresent(user, method, args[0].(*big.Int))
+	case "cancel_sale", "check_to_buy", "cancel_to_buy":
+		return explainSale(user, value, method, args[0].(*big.Int), nil)
+	case "confirm_sale":
+		return explainSale(user, value, method, args[0].(*big.Int), args[1].(*big.Int))
+	case "to_rent", "cancel_rent", "finish_rent":
+		return explainRent(user, value, method, args[0].(*big.Int))
+	}
+	return ""
+}
+
+func explainAdmin(user *UserType) string {
+	iamAdmin, err := Backend.IamAdmin(user)
+	if err == nil && !iamAdmin {
+		return "only admin can create estates"
+	}
+	return ""
+}
+
+// Checks of is_owner and status_OK modifiers.
+func explainEstate(user *UserType, estateId *big.Int) string {
+	num, err := Backend.EstatesNumber(user)
+	if err != nil {
+		return ""
+	}
+	if estateId.Cmp(num) != -1 {
+		return fmt.Sprintf("estate %s does not exist", estateId)
+	}
+	estate, err := Backend.GetEstates(user, estateId)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case estate.Owner != user.AddressEth:
+		return fmt.Sprintf("estate %s is owned by %s", estateId, estate.Owner.Hex())
+	case estate.PresentStatus:
+		return fmt.Sprintf("estate %s is already presented", estateId)
+	case estate.SaleStatus:
+		return fmt.Sprintf("estate %s is already on sale", estateId)
+	case estate.RentStatus:
+		return fmt.Sprintf("estate %s is already in rent", estateId)
+	}
+	return ""
+}
+
+func explainPresent(user *UserType, method string, presentId *big.Int) string {
+	num, err := Backend.PresentsNumber(user)
+	if err != nil {
+		return ""
+	}
+	if presentId.Cmp(num) != -1 {
+		return fmt.Sprintf("present %s does not exist", presentId)
+	}
+	present, err := Backend.GetPresents(user, presentId)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case method == "cancel_present" && present.AddressFrom != user.AddressEth:
+		return fmt.Sprintf("only the sender can cancel present %s", presentId)
+	case method == "confirm_present" && present.AddressTo != user.AddressEth:
+		return fmt.Sprintf("only the recipient can confirm present %s", presentId)
+	case present.Finished:
+		return fmt.Sprintf("present %s is already finished", presentId)
+	}
+	return ""
+}
+
+func explainSale(user *UserType, value *big.Int, method string, saleId *big.Int, saleTo *big.Int) string {
+	num, err := Backend.SalesNumber(user)
+	if err != nil {
+		return ""
+	}
+	if saleId.Cmp(num) != -1 {
+		return fmt.Sprintf("sale %s does not exist", saleId)
+	}
+	sale, err := Backend.GetSales(user, saleId)
+	if err != nil {
+		return ""
+	}
+	if sale.Finished {
+		return fmt.Sprintf("sale %s is already finished", saleId)
+	}
+	switch method {
+	case "cancel_sale", "confirm_sale":
+		if sale.Owner != user.AddressEth {
+			return fmt.Sprintf("only the seller can %s sale %s", strings.Split(method, "_")[0], saleId)
+		}
+	case "check_to_buy":
+		if sale.Owner == user.AddressEth {
+			return fmt.Sprintf("seller can not bid on own sale %s", saleId)
+		}
+		if value.Cmp(sale.Price) == -1 {
+			return fmt.Sprintf("bid %s is lower than price %s of sale %s", value, sale.Price, saleId)
+		}
+		if SaleHasCustomer(sale, user.AddressHex) {
+			return fmt.Sprintf("you already bid on sale %s", saleId)
+		}
+	}
+	if method == "confirm_sale" {
+		if saleTo.Sign() < 0 || saleTo.Cmp(big.NewInt(int64(len(sale.Customers)))) != -1 {
+			return fmt.Sprintf("sale %s has no customer %s", saleId, saleTo)
+		}
+		if sale.Prices[saleTo.Int64()].Sign() == 0 {
+			return fmt.Sprintf("customer %s withdrew the bid on sale %s", saleTo, saleId)
+		}
+	}
+	return ""
+}
+
+func explainRent(user *UserType, value *big.Int, method string, rentId *big.Int) string {
+	num, err := Backend.RentsNumber(user)
+	if err != nil {
+		return ""
+	}
+	if rentId.Cmp(num) != -1 {
+		return fmt.Sprintf("rent %s does not exist", rentId)
+	}
+	rent, err := Backend.GetRents(user, rentId)
+	if err != nil {
+		return ""
+	}
+	if rent.Finished {
+		return fmt.Sprintf("rent %s is already finished", rentId)
+	}
+	taken := rent.RenterAddress != (common.Address{})
+	switch method {
+	case "to_rent":
+		switch {
+		case taken:
+			return fmt.Sprintf("rent %s is already taken", rentId)
+		case rent.OwnerAddress == user.AddressEth:
+			return fmt.Sprintf("owner can not take own rent %s", rentId)
+		case rent.Money.Cmp(value) != 0:
+			return fmt.Sprintf("rent %s costs exactly %s wei, not %s", rentId, rent.Money, value)
+		}
+	case "cancel_rent":
+		switch {
+		case rent.OwnerAddress != user.AddressEth:
+			return fmt.Sprintf("only the owner can cancel rent %s", rentId)
+		case taken:
+			return fmt.Sprintf("rent %s is already taken, finish it after deadline", rentId)
+		}
+	case "finish_rent":
+		estate, err := Backend.GetEstates(user, rent.EstateId)
+		if err != nil {
+			return ""
+		}
+		switch {
+		case estate.Owner != user.AddressEth:
+			return fmt.Sprintf("only the owner of estate %s can finish rent %s", rent.EstateId, rentId)
+		case rent.Deadline.Cmp(big.NewInt(time.Now().Unix())) != -1:
+			return fmt.Sprintf("rent %s can be finished after %s", rentId, RentDeadline(rent))
+		}
+	}
+	return ""
+}
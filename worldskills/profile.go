@@ -0,0 +1,133 @@
+package worldskills
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Network with its own deployment, e.g. local Ganache or shared test node.
+// Profiles are kept in "profiles" of JSON config by name. Empty settings
+// are taken from the rest of config. Account is keystore file used by
+// client and deploy when no private key is given.
+type ProfileType struct {
+	RPC string `json:"rpc"`
+	ChainId string `json:"chain_id"`
+	Contract string `json:"contract"`
+	Account string `json:"account"`
+}
+
+// Config, ClientETH, ContractAddr, Instance, Backend, Nonces and Index
+// belong to one network. SwitchProfile replaces them holding NetworkLock,
+// servers hold it to read while they use them.
+var NetworkLock sync.RWMutex
+
+// Copy settings of cfg.Profile to cfg, nothing if it is empty.
+func (cfg *ConfigType) applyProfile() error {
+	if cfg.Profile == "" {
+		return nil
+	}
+	profile, ok := cfg.Profiles[cfg.Profile]
+	if !ok || profile == nil {
+		return fmt.Errorf("profile %q is not in config", cfg.Profile)
+	}
+	for _, field := range []struct{ value string; to *string }{
+		{profile.RPC, &cfg.RPC},
+		{profile.ChainId, &cfg.ChainId},
+		{profile.Contract, &cfg.Contract},
+		{profile.Account, &cfg.Account},
+	} {
+		if field.value != "" {
+			*field.to = field.value
+		}
+	}
+	return nil
+}
+
+// Sorted names of profiles in config.
+func (cfg *ConfigType) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Connect to node and contract of profile and restart Index on them.
+// Settings of profile override environment and flags. If the new network
+// can not be reached, the old one is kept, and so it is if profile has
+// no rpc. Index is stopped before the network is replaced, so its poll
+// does not see the switch.
+func SwitchProfile(name string) error {
+	NetworkLock.Lock()
+	defer NetworkLock.Unlock()
+	if Memory != nil {
+		return WithExit(EXIT_CONFIG, fmt.Errorf("memory backend does not use profiles"))
+	}
+	var (
+		oldConfig = Config
+		oldClient = ClientETH
+		oldAddr = ContractAddr
+		oldInstance = Instance
+		oldBackend = Backend
+		oldNonces = Nonces
+		oldIndex = Index
+	)
+	// Contract and chain id of the old network are not kept.
+	cfg := *oldConfig
+	cfg.Profile = name
+	cfg.Contract = ""
+	cfg.ChainId = ""
+	if err := cfg.applyProfile(); err != nil {
+		return WithExit(EXIT_CONFIG, err)
+	}
+	// Without rpc the node of the old network would be kept.
+	if cfg.Profiles[name].RPC == "" {
+		return WithExit(EXIT_CONFIG, fmt.Errorf("profile %s has no rpc", name))
+	}
+	if oldIndex != nil {
+		oldIndex.Stop()
+	}
+	restore := func() {
+		// Node of the new network is not used by anything yet.
+		if client, ok := ClientETH.(interface{ Close() }); ok && ClientETH != oldClient {
+			client.Close()
+		}
+		Config = oldConfig
+		ClientETH = oldClient
+		ContractAddr = oldAddr
+		Instance = oldInstance
+		Backend = oldBackend
+		Nonces = oldNonces
+		if oldIndex == nil {
+			return
+		}
+		// Stopped Index still has records of the old network.
+		if index, err := StartIndexer(); err == nil {
+			Index = index
+		} else {
+			Logger.Println("indexer:", err)
+		}
+	}
+	Config = &cfg
+	if err := connectChain(); err != nil {
+		restore()
+		return err
+	}
+	if oldIndex != nil {
+		index, err := StartIndexer()
+		if err != nil {
+			restore()
+			return WithExit(EXIT_CONTRACT, fmt.Errorf("start indexer of profile %s: %w", name, err))
+		}
+		Index = index
+	}
+	// Receipts of the old network may still be waited for without lock,
+	// see WaitTxUnlocked, so its node is closed after the longest wait.
+	if client, ok := oldClient.(interface{ Close() }); ok {
+		time.AfterFunc(oldConfig.TxWait(), client.Close)
+	}
+	return nil
+}
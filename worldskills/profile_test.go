@@ -0,0 +1,89 @@
+package worldskills_test
+
+import (
+	"time"
+	"testing"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/number571/contract-interfaces/worldskills"
+	"github.com/number571/contract-interfaces/worldskills/chaintest"
+)
+
+// Switch moves contract and Index to the network of profile and closes
+// the old node after receipt timeout, a profile which can not be used
+// keeps the current network.
+func TestSwitchProfile(t *testing.T) {
+	users, addresses := chaintest.SetupProfiles(t, []string{"a", "b"}, "-txtimeout:100ms")
+	chain := worldskills.ClientETH.(*chaintest.ChainType)
+	if worldskills.ContractAddr != addresses["a"] {
+		t.Fatalf("contract %s, want %s of profile a", worldskills.ContractAddr.Hex(), addresses["a"].Hex())
+	}
+	createEstate(t, users.Admin, users.First)
+	index, err := worldskills.StartIndexer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	worldskills.Index = index
+	if n := len(worldskills.Index.Estates()); n != 1 {
+		t.Fatalf("index of profile a has %d estates, want 1", n)
+	}
+
+	if err := worldskills.SwitchProfile("b"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-chain.Closed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("node of profile a is not closed")
+	}
+	if worldskills.ContractAddr != addresses["b"] || worldskills.Config.Profile != "b" {
+		t.Fatalf("contract %s of profile %s, want %s of b", worldskills.ContractAddr.Hex(), worldskills.Config.Profile, addresses["b"].Hex())
+	}
+	if worldskills.Index == index {
+		t.Fatal("index is not restarted")
+	}
+	if n := len(worldskills.Index.Estates()); n != 0 {
+		t.Fatalf("index of profile b has %d estates, want 0", n)
+	}
+	number, err := worldskills.Backend.EstatesNumber(users.Admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number.Sign() != 0 {
+		t.Fatalf("contract of profile b has %s estates, want 0", number)
+	}
+
+	index = worldskills.Index
+	worldskills.Config.Profiles["norpc"] = &worldskills.ProfileType{Contract: addresses["a"].Hex()}
+	for _, name := range []string{"c", "norpc"} {
+		if err := worldskills.SwitchProfile(name); err == nil {
+			t.Fatalf("switch to profile %s succeeds", name)
+		}
+		if worldskills.ContractAddr != addresses["b"] || worldskills.Config.Profile != "b" || worldskills.Index != index {
+			t.Fatalf("failed switch to %s changes network to contract %s of profile %s", name, worldskills.ContractAddr.Hex(), worldskills.Config.Profile)
+		}
+	}
+}
+
+// Switch is not blocked by request which waits for receipt.
+func TestWaitTxUnlocked(t *testing.T) {
+	chaintest.Setup(t, "memory", "-txtimeout:2s")
+	tx := types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil)
+	waited := make(chan error)
+	worldskills.NetworkLock.RLock()
+	go func() {
+		_, err := worldskills.WaitTxUnlocked(tx)
+		worldskills.NetworkLock.RUnlock()
+		waited <- err
+	}()
+	start := time.Now()
+	worldskills.NetworkLock.Lock()
+	if time.Since(start) > time.Second {
+		t.Fatal("lock is taken after wait")
+	}
+	worldskills.NetworkLock.Unlock()
+	if err := <-waited; err == nil {
+		t.Fatal("tx which is never sent is mined")
+	}
+}
@@ -0,0 +1,197 @@
+package worldskills
+
+import (
+	"fmt"
+	"math/big"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	contract "github.com/number571/contract-interfaces/contracts"
+)
+
+// Estate registry of contract.sol. Getters are called from user,
+// transactions are signed by auth and returned before they are mined.
+type Registry interface {
+	IamAdmin(user *UserType) (bool, error)
+	EstatesNumber(user *UserType) (*big.Int, error)
+	PresentsNumber(user *UserType) (*big.Int, error)
+	SalesNumber(user *UserType) (*big.Int, error)
+	RentsNumber(user *UserType) (*big.Int, error)
+	GetEstates(user *UserType, index *big.Int) (*Estate, error)
+	GetPresents(user *UserType, index *big.Int) (*Present, error)
+	GetSales(user *UserType, index *big.Int) (*Sale, error)
+	GetRents(user *UserType, index *big.Int) (*Rent, error)
+
+	CreateEstate(auth *bind.TransactOpts, owner common.Address, info string, squere *big.Int, usefulSquere *big.Int) (*types.Transaction, error)
+	CreatePresent(auth *bind.TransactOpts, estateId *big.Int, addressTo common.Address) (*types.Transaction, error)
+	CancelPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error)
+	ConfirmPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error)
+	CreateSale(auth *bind.TransactOpts, estateId *big.Int, price *big.Int) (*types.Transaction, error)
+	CancelSale(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error)
+	CheckToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error)
+	CancelToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error)
+	ConfirmSale(auth *bind.TransactOpts, saleId *big.Int, saleTo *big.Int) (*types.Transaction, error)
+	CreateRent(auth *bind.TransactOpts, estateId *big.Int, days *big.Int, money *big.Int) (*types.Transaction, error)
+	ToRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error)
+	CancelRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error)
+	FinishRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error)
+}
+
+// Registry used by client and gclient, set by SetupChain.
+var (
+	Backend Registry
+)
+
+// Registry of deployed contract through abigen bindings.
+type contractRegistry struct {
+	instance *contract.Contract
+}
+
+func NewContractRegistry(instance *contract.Contract) Registry {
+	return &contractRegistry{instance: instance}
+}
+
+func (reg *contractRegistry) IamAdmin(user *UserType) (bool, error) {
+	return reg.instance.IamAdmin(&bind.CallOpts{From: user.AddressEth})
+}
+
+func (reg *contractRegistry) EstatesNumber(user *UserType) (*big.Int, error) {
+	return reg.instance.GetEstatesNumber(&bind.CallOpts{From: user.AddressEth})
+}
+
+func (reg *contractRegistry) PresentsNumber(user *UserType) (*big.Int, error) {
+	return reg.instance.GetPresentsNumber(&bind.CallOpts{From: user.AddressEth})
+}
+
+func (reg *contractRegistry) SalesNumber(user *UserType) (*big.Int, error) {
+	return reg.instance.GetSalesNumber(&bind.CallOpts{From: user.AddressEth})
+}
+
+func (reg *contractRegistry) RentsNumber(user *UserType) (*big.Int, error) {
+	return reg.instance.GetRentsNumber(&bind.CallOpts{From: user.AddressEth})
+}
+
+func (reg *contractRegistry) GetEstates(user *UserType, index *big.Int) (*Estate, error) {
+	// (*big.Int, common.Address, string, *big.Int, *big.Int, common.Address, error)
+	id, owner, info, squere, usefulsquere, renteraddress, err := reg.instance.GetEstates(&bind.CallOpts{From: user.AddressEth}, index)
+	if err != nil {
+		return nil, fmt.Errorf("get estate %s: %w", index, err)
+	}
+	presentS, saleS, rentS, err := reg.instance.GetEstatesStatuses(&bind.CallOpts{From: user.AddressEth}, index)
+	if err != nil {
+		return nil, fmt.Errorf("get estate %s: %w", index, err)
+	}
+	return &Estate{
+		Id: id,
+		Owner: owner,
+		Info: info,
+		Squere: squere,
+		UsefulSquere: usefulsquere,
+		RenterAddress: renteraddress,
+		PresentStatus: presentS,
+		SaleStatus: saleS,
+		RentStatus: rentS,
+	}, nil
+}
+
+func (reg *contractRegistry) GetPresents(user *UserType, index *big.Int) (*Present, error) {
+	// (*big.Int, common.Address, common.Address, bool, error)
+	id, from, to, finished, err := reg.instance.GetPresents(&bind.CallOpts{From: user.AddressEth}, index)
+	if err != nil {
+		return nil, fmt.Errorf("get present %s: %w", index, err)
+	}
+	return &Present{
+		Id: index,
+		EstateId: id,
+		AddressFrom: from,
+		AddressTo: to,
+		Finished: finished,
+	}, nil
+}
+
+func (reg *contractRegistry) GetSales(user *UserType, index *big.Int) (*Sale, error) {
+	// (*big.Int, common.Address, *big.Int, []common.Address, []*big.Int, bool, error)
+	id, owner, price, customers, prices, finished, err := reg.instance.GetSales(&bind.CallOpts{From: user.AddressEth}, index)
+	if err != nil {
+		return nil, fmt.Errorf("get sale %s: %w", index, err)
+	}
+	return &Sale{
+		Id: index,
+		EstateId: id,
+		Owner: owner,
+		Price: price,
+		Customers: customers,
+		Prices: prices,
+		Finished: finished,
+	}, nil
+}
+
+func (reg *contractRegistry) GetRents(user *UserType, index *big.Int) (*Rent, error) {
+	// (*big.Int, common.Address, common.Address, *big.Int, *big.Int, *big.Int, bool, error)
+	id, owner, renter, days, money, deadline, finished, err := reg.instance.GetRents(&bind.CallOpts{From: user.AddressEth}, index)
+	if err != nil {
+		return nil, fmt.Errorf("get rent %s: %w", index, err)
+	}
+	return &Rent{
+		Id: index,
+		EstateId: id,
+		OwnerAddress: owner,
+		RenterAddress: renter,
+		Time: days,
+		Money: money,
+		Deadline: deadline,
+		Finished: finished,
+	}, nil
+}
+
+func (reg *contractRegistry) CreateEstate(auth *bind.TransactOpts, owner common.Address, info string, squere *big.Int, usefulSquere *big.Int) (*types.Transaction, error) {
+	return reg.instance.CreateEstate(auth, owner, info, squere, usefulSquere)
+}
+
+func (reg *contractRegistry) CreatePresent(auth *bind.TransactOpts, estateId *big.Int, addressTo common.Address) (*types.Transaction, error) {
+	return reg.instance.CreatePresent(auth, estateId, addressTo)
+}
+
+func (reg *contractRegistry) CancelPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error) {
+	return reg.instance.CancelPresent(auth, presentId)
+}
+
+func (reg *contractRegistry) ConfirmPresent(auth *bind.TransactOpts, presentId *big.Int) (*types.Transaction, error) {
+	return reg.instance.ConfirmPresent(auth, presentId)
+}
+
+func (reg *contractRegistry) CreateSale(auth *bind.TransactOpts, estateId *big.Int, price *big.Int) (*types.Transaction, error) {
+	return reg.instance.CreateSale(auth, estateId, price)
+}
+
+func (reg *contractRegistry) CancelSale(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return reg.instance.CancelSale(auth, saleId)
+}
+
+func (reg *contractRegistry) CheckToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return reg.instance.CheckToBuy(auth, saleId)
+}
+
+func (reg *contractRegistry) CancelToBuy(auth *bind.TransactOpts, saleId *big.Int) (*types.Transaction, error) {
+	return reg.instance.CancelToBuy(auth, saleId)
+}
+
+func (reg *contractRegistry) ConfirmSale(auth *bind.TransactOpts, saleId *big.Int, saleTo *big.Int) (*types.Transaction, error) {
+	return reg.instance.ConfirmSale(auth, saleId, saleTo)
+}
+
+func (reg *contractRegistry) CreateRent(auth *bind.TransactOpts, estateId *big.Int, days *big.Int, money *big.Int) (*types.Transaction, error) {
+	return reg.instance.CreateRent(auth, estateId, days, money)
+}
+
+func (reg *contractRegistry) ToRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return reg.instance.ToRent(auth, rentId)
+}
+
+func (reg *contractRegistry) CancelRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return reg.instance.CancelRent(auth, rentId)
+}
+
+func (reg *contractRegistry) FinishRent(auth *bind.TransactOpts, rentId *big.Int) (*types.Transaction, error) {
+	return reg.instance.FinishRent(auth, rentId)
+}
@@ -0,0 +1,235 @@
+package worldskills
+
+import (
+	"fmt"
+	"encoding/json"
+	"encoding/binary"
+	bolt "go.etcd.io/bbolt"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	bucketMeta     = []byte("meta")
+	bucketBlocks   = []byte("blocks")
+	bucketEstates  = []byte("estates")
+	bucketPresents = []byte("presents")
+	bucketSales    = []byte("sales")
+	bucketRents    = []byte("rents")
+
+	keyContract  = []byte("contract")
+	keyLastBlock = []byte("last_block")
+)
+
+// Persistent copy of IndexerType in bbolt file. Records are stored as JSON
+// by index, blocks keep hashes and touched records of the last REORG_DEPTH
+// blocks to roll back after reorg.
+type StoreType struct {
+	db *bolt.DB
+}
+
+type storeState struct {
+	lastBlock uint64
+	blocks map[uint64]*blockRecord
+	estates []*Estate
+	presents []*Present
+	sales []*Sale
+	rents []*Rent
+}
+
+// Open cache file for contract. Cache of other contract is dropped.
+func openStore(path string, contractAddr common.Address) (*StoreType, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open cache %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		meta := tx.Bucket(bucketMeta)
+		if meta != nil && common.BytesToAddress(meta.Get(keyContract)) != contractAddr {
+			for _, name := range [][]byte{bucketMeta, bucketBlocks, bucketEstates, bucketPresents, bucketSales, bucketRents} {
+				if tx.Bucket(name) == nil {
+					continue
+				}
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+		for _, name := range [][]byte{bucketMeta, bucketBlocks, bucketEstates, bucketPresents, bucketSales, bucketRents} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketMeta).Put(keyContract, contractAddr.Bytes())
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init cache %s: %w", path, err)
+	}
+	return &StoreType{db: db}, nil
+}
+
+func (store *StoreType) Close() error {
+	return store.db.Close()
+}
+
+func (store *StoreType) Load() (*storeState, error) {
+	state := &storeState{
+		blocks: make(map[uint64]*blockRecord),
+	}
+	err := store.db.View(func(tx *bolt.Tx) error {
+		if data := tx.Bucket(bucketMeta).Get(keyLastBlock); data != nil {
+			state.lastBlock = binary.BigEndian.Uint64(data)
+		}
+		err := tx.Bucket(bucketBlocks).ForEach(func(k, v []byte) error {
+			block := new(blockRecord)
+			if err := json.Unmarshal(v, block); err != nil {
+				return err
+			}
+			state.blocks[binary.BigEndian.Uint64(k)] = block
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(bucketEstates).ForEach(func(k, v []byte) error {
+			estate := new(Estate)
+			state.estates = append(state.estates, estate)
+			return json.Unmarshal(v, estate)
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(bucketPresents).ForEach(func(k, v []byte) error {
+			present := new(Present)
+			state.presents = append(state.presents, present)
+			return json.Unmarshal(v, present)
+		})
+		if err != nil {
+			return err
+		}
+		err = tx.Bucket(bucketSales).ForEach(func(k, v []byte) error {
+			sale := new(Sale)
+			state.sales = append(state.sales, sale)
+			return json.Unmarshal(v, sale)
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketRents).ForEach(func(k, v []byte) error {
+			rent := new(Rent)
+			state.rents = append(state.rents, rent)
+			return json.Unmarshal(v, rent)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load cache: %w", err)
+	}
+	return state, nil
+}
+
+// Save dirty records of state and its blocks in one transaction.
+// Blocks missing in state are deleted, and so are records beyond the
+// numbers of state, which are dropped after reorg.
+func (store *StoreType) Save(state *storeState, dirty *dirtySet) error {
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketMeta).Put(keyLastBlock, storeKey(state.lastBlock)); err != nil {
+			return err
+		}
+		blocks := tx.Bucket(bucketBlocks)
+		var stale [][]byte
+		err := blocks.ForEach(func(k, v []byte) error {
+			if _, ok := state.blocks[binary.BigEndian.Uint64(k)]; !ok {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := blocks.Delete(k); err != nil {
+				return err
+			}
+		}
+		for number, block := range state.blocks {
+			if err := storePut(blocks, number, block); err != nil {
+				return err
+			}
+		}
+		if err := storeTruncate(tx.Bucket(bucketEstates), uint64(len(state.estates))); err != nil {
+			return err
+		}
+		for i := range dirty.estates {
+			if i < uint64(len(state.estates)) {
+				if err := storePut(tx.Bucket(bucketEstates), i, state.estates[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if err := storeTruncate(tx.Bucket(bucketPresents), uint64(len(state.presents))); err != nil {
+			return err
+		}
+		for i := range dirty.presents {
+			if i < uint64(len(state.presents)) {
+				if err := storePut(tx.Bucket(bucketPresents), i, state.presents[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if err := storeTruncate(tx.Bucket(bucketSales), uint64(len(state.sales))); err != nil {
+			return err
+		}
+		for i := range dirty.sales {
+			if i < uint64(len(state.sales)) {
+				if err := storePut(tx.Bucket(bucketSales), i, state.sales[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if err := storeTruncate(tx.Bucket(bucketRents), uint64(len(state.rents))); err != nil {
+			return err
+		}
+		for i := range dirty.rents {
+			if i < uint64(len(state.rents)) {
+				if err := storePut(tx.Bucket(bucketRents), i, state.rents[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save cache: %w", err)
+	}
+	return nil
+}
+
+func storePut(bucket *bolt.Bucket, index uint64, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(storeKey(index), data)
+}
+
+// Delete records of bucket from index length on.
+func storeTruncate(bucket *bolt.Bucket, length uint64) error {
+	var stale [][]byte
+	cursor := bucket.Cursor()
+	for k, _ := cursor.Seek(storeKey(length)); k != nil; k, _ = cursor.Next() {
+		stale = append(stale, append([]byte(nil), k...))
+	}
+	for _, k := range stale {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Big endian keys keep records ordered by index in bucket.
+func storeKey(index uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, index)
+	return key
+}
@@ -0,0 +1,760 @@
+package worldskills
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	"errors"
+	"strings"
+	"context"
+	"math/big"
+	"crypto/ecdsa"
+	contract "github.com/number571/contract-interfaces/contracts"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+type UserType struct {
+	Purse string
+	AddressHex string
+	AddressEth common.Address
+	PublicKey *ecdsa.PublicKey
+	PrivateKey *ecdsa.PrivateKey
+}
+
+type Estate struct{
+    Id *big.Int
+    Owner common.Address
+    Info string
+    Squere *big.Int
+    UsefulSquere *big.Int
+    RenterAddress common.Address
+    PresentStatus bool
+    SaleStatus bool
+    RentStatus bool
+}
+
+type Present struct {
+	Id *big.Int
+	EstateId *big.Int
+	AddressFrom common.Address
+	AddressTo common.Address
+	Finished bool
+}
+
+type Sale struct {
+	Id *big.Int
+	EstateId *big.Int
+	Owner common.Address
+	Price *big.Int
+	Customers []common.Address
+	Prices []*big.Int
+	Finished bool
+}
+
+// Event of contract. Record is "estate", "present", "sale" or "rent" and
+// Id is index of that record. From, To and Value are set if event has them.
+type EventType struct {
+	Name string
+	Record string
+	Id *big.Int
+	EstateId *big.Int
+	From common.Address
+	To common.Address
+	Value *big.Int
+	BlockNumber uint64
+	LogIndex uint
+	TxHash common.Hash
+}
+
+// Step of estate history, Owner is owner of estate after the step.
+type HistoryType struct {
+	Event *EventType
+	Time time.Time
+	Owner common.Address
+}
+
+type ReceiptType struct {
+	TxHash common.Hash
+	BlockNumber *big.Int
+	GasUsed uint64
+	Success bool
+}
+
+type Rent struct {
+	Id *big.Int
+	EstateId *big.Int
+	OwnerAddress common.Address
+	RenterAddress common.Address
+	Time *big.Int
+	Money *big.Int
+	Deadline *big.Int
+	Finished bool
+}
+
+// Node methods used by worldskills. It is an ethclient.Client, or
+// MemoryRegistry with -backend:memory.
+type ChainBackend interface {
+	bind.ContractBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+	NetworkID(ctx context.Context) (*big.Int, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
+var (
+	Config *ConfigType
+	ClientETH ChainBackend
+	ContractAddr common.Address
+	Instance *contract.Contract
+	// Set with -backend:memory, nil otherwise.
+	Memory *MemoryRegistry
+)
+
+// Load config from command line and connect to node and contract,
+// or start MemoryRegistry in place of both with -backend:memory.
+func SetupChain(args []string) error {
+	var err error
+	Config, err = LoadConfig(args)
+	if err != nil {
+		return WithExit(EXIT_CONFIG, fmt.Errorf("load config: %w", err))
+	}
+	if Config.Backend == "memory" {
+		return setupMemory()
+	}
+	return connectChain()
+}
+
+// Connect to node by url of Config.RPC. Tests replace it to reach
+// a simulated chain.
+var DialETH = func(address string) (ChainBackend, error) {
+	client, err := ConnectToETH(address)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Connect to node of Config and to its contract.
+func connectChain() error {
+	var err error
+	ClientETH, err = DialETH(Config.RPC)
+	if err != nil {
+		return WithExit(EXIT_CONNECT, err)
+	}
+	if _, err := NodeChainId(); err != nil {
+		return WithExit(EXIT_CONFIG, err)
+	}
+	networkId, err := ClientETH.NetworkID(context.Background())
+	if err != nil {
+		return WithExit(EXIT_CONNECT, fmt.Errorf("get network id: %w", err))
+	}
+	address, err := Config.ContractAddress(networkId)
+	if err != nil {
+		return WithExit(EXIT_CONTRACT, err)
+	}
+	if !common.IsHexAddress(address) {
+		return WithExit(EXIT_CONTRACT, fmt.Errorf("contract address %q is invalid", address))
+	}
+	Nonces = NewNonceManager(ClientETH)
+	ContractAddr = common.HexToAddress(address)
+	Instance, err = ConnectToContract(ContractAddr, ClientETH)
+	if err != nil {
+		return WithExit(EXIT_CONTRACT, err)
+	}
+	Backend = NewContractRegistry(Instance)
+	return nil
+}
+
+func setupMemory() error {
+	var err error
+	ContractAddr = common.HexToAddress(MEMORY_CONTRACT)
+	Memory, err = NewMemoryRegistry(common.HexToAddress(Config.MemoryAdmin))
+	if err != nil {
+		return WithExit(EXIT_CONTRACT, err)
+	}
+	ClientETH = Memory
+	Nonces = NewNonceManager(ClientETH)
+	// Bindings are only used to parse events of memory blocks.
+	Instance, err = contract.NewContract(ContractAddr, ClientETH)
+	if err != nil {
+		return WithExit(EXIT_CONTRACT, fmt.Errorf("bind contract: %w", err))
+	}
+	Backend = Memory
+	return nil
+}
+
+func LoadUser(purse string) (*UserType, error) {
+	priv, err := crypto.HexToECDSA(purse)
+	if err != nil {
+		return nil, fmt.Errorf("load private key: %w", err)
+	}
+	pub, ok := priv.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("load private key: public key is not ecdsa")
+	}
+	addressHex := crypto.PubkeyToAddress(*pub).Hex()
+	addressEth  := common.HexToAddress(addressHex)
+	return &UserType{
+		Purse:      purse,
+		AddressHex: addressHex,
+		AddressEth: addressEth,
+		PublicKey:  pub,
+		PrivateKey: priv,
+	}, nil
+}
+
+// Contract must have code at address, otherwise every call returns empty data.
+func ConnectToContract(contractAddr common.Address, clientEth ChainBackend) (*contract.Contract, error) {
+	code, err := clientEth.CodeAt(context.Background(), contractAddr, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get code of contract %s: %w", contractAddr.Hex(), err)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf("no contract code at address %s", contractAddr.Hex())
+	}
+	instance, err := contract.NewContract(contractAddr, clientEth)
+	if err != nil {
+		return nil, fmt.Errorf("bind contract %s: %w", contractAddr.Hex(), err)
+	}
+	return instance, nil
+}
+
+// Dial does not touch the node over http, so network id is requested to check it.
+func ConnectToETH(address string) (*ethclient.Client, error) {
+	client, err := ethclient.Dial(address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to ETH %s: %w", address, err)
+	}
+	if _, err := client.NetworkID(context.Background()); err != nil {
+		return nil, fmt.Errorf("connect to ETH %s: %w", address, err)
+	}
+	return client, nil
+}
+
+// Chain id of node (eth_chainId). It must be Config.ChainId if that is set,
+// so transactions are not signed for another network than the profile.
+// Memory backend has its own chain and ignores Config.ChainId.
+func NodeChainId() (*big.Int, error) {
+	chainId, err := ClientETH.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("get chain id: %w", err)
+	}
+	if expected := Config.ChainIdValue(); Memory == nil && expected != nil && expected.Cmp(chainId) != 0 {
+		return nil, fmt.Errorf("node is on chain %s, config expects chain %s", chainId, expected)
+	}
+	return chainId, nil
+}
+
+// Transactions are signed with EIP-155 chain id of node, checked by NodeChainId.
+// Nonce is left empty, SendTx takes it from Nonces.
+// Gas is set by Preflight with SetGas.
+func ResetAuth(user *UserType) (*bind.TransactOpts, error) {
+	chainId, err := NodeChainId()
+	if err != nil {
+		return nil, err
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(user.PrivateKey, chainId)
+	if err != nil {
+		return nil, fmt.Errorf("create signer: %w", err)
+	}
+	auth.Value = big.NewInt(0)
+
+	return auth, nil
+}
+
+// Wait until tx is mined, but not longer than Config.TxTimeout.
+func WaitTx(tx *types.Transaction) (*ReceiptType, error) {
+	return waitTx(ClientETH, Config.TxWait(), tx)
+}
+
+// WaitTx for caller which holds NetworkLock to read. The lock is released
+// while waiting, so SwitchProfile is not blocked by a slow tx, and taken
+// again before return. Tx is waited for on the node it was sent to.
+func WaitTxUnlocked(tx *types.Transaction) (*ReceiptType, error) {
+	client, timeout := ClientETH, Config.TxWait()
+	NetworkLock.RUnlock()
+	defer NetworkLock.RLock()
+	return waitTx(client, timeout, tx)
+}
+
+func waitTx(client ChainBackend, timeout time.Duration, tx *types.Transaction) (*ReceiptType, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	receipt, err := bind.WaitMined(ctx, client, tx)
+	if err != nil {
+		return nil, fmt.Errorf("wait tx %s: %w", tx.Hash().Hex(), err)
+	}
+	return &ReceiptType{
+		TxHash: receipt.TxHash,
+		BlockNumber: receipt.BlockNumber,
+		GasUsed: receipt.GasUsed,
+		Success: receipt.Status == types.ReceiptStatusSuccessful,
+	}, nil
+}
+
+func (receipt *ReceiptType) String() string {
+	status := "success"
+	if !receipt.Success {
+		status = "reverted"
+	}
+	return fmt.Sprintf("Block: %s, Gas used: %d, Status: %s", receipt.BlockNumber, receipt.GasUsed, status)
+}
+
+// Read events of contract in blocks from..to, to == nil means latest block.
+func FilterEvents(from uint64, to *uint64) ([]*EventType, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		Addresses: []common.Address{ContractAddr},
+	}
+	if to != nil {
+		query.ToBlock = new(big.Int).SetUint64(*to)
+	}
+	logs, err := ClientETH.FilterLogs(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("filter events: %w", err)
+	}
+	events := make([]*EventType, 0, len(logs))
+	for _, log := range logs {
+		event, err := ParseEvent(log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Send events of new blocks to sink every Config.PollInterval until stop
+// is closed. Polling works over http, unlike log subscriptions. Errors of
+// a poll are written to Logger.
+func WatchEvents(sink chan<- *EventType, stop <-chan struct{}) error {
+	last, err := ClientETH.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("get block number: %w", err)
+	}
+	go func() {
+		ticker := time.NewTicker(Config.PollWait())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			// Failed poll is retried on the next tick from the same block.
+			head, err := ClientETH.BlockNumber(context.Background())
+			if err != nil {
+				Logger.Println("watch: get block number:", err)
+				continue
+			}
+			if head <= last {
+				continue
+			}
+			events, err := FilterEvents(last+1, &head)
+			if err != nil {
+				Logger.Println("watch:", err)
+				continue
+			}
+			for _, event := range events {
+				select {
+				case sink <- event:
+				case <-stop:
+					return
+				}
+			}
+			last = head
+		}
+	}()
+	return nil
+}
+
+// Events of estate in order. EstateCreated has estate_id as first indexed
+// argument, all other events as second one.
+func FilterEstateEvents(estateId *big.Int) ([]*EventType, error) {
+	parsed, err := contractABI()
+	if err != nil {
+		return nil, err
+	}
+	topic := common.BigToHash(estateId)
+	queries := []ethereum.FilterQuery{
+		{
+			FromBlock: big.NewInt(0),
+			Addresses: []common.Address{ContractAddr},
+			Topics: [][]common.Hash{{parsed.Events["EstateCreated"].ID}, {topic}},
+		},
+		{
+			FromBlock: big.NewInt(0),
+			Addresses: []common.Address{ContractAddr},
+			Topics: [][]common.Hash{nil, nil, {topic}},
+		},
+	}
+	var events []*EventType
+	for _, query := range queries {
+		logs, err := ClientETH.FilterLogs(context.Background(), query)
+		if err != nil {
+			return nil, fmt.Errorf("filter events: %w", err)
+		}
+		for _, log := range logs {
+			event, err := ParseEvent(log)
+			if err != nil {
+				return nil, err
+			}
+			if event.EstateId.Cmp(estateId) != 0 {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber < events[j].BlockNumber
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+	return events, nil
+}
+
+// Replay events of estate to get its creation by admin, confirmed presents,
+// completed sales and rents with block times. Offers which were cancelled
+// or not taken do not change estate and are skipped.
+func GetEstateHistory(estateId *big.Int) ([]*HistoryType, error) {
+	events, err := FilterEstateEvents(estateId)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		history []*HistoryType
+		times = make(map[uint64]time.Time)
+		owner common.Address
+	)
+	for _, event := range events {
+		switch event.Name {
+		case "EstateCreated", "PresentConfirmed", "SaleConfirmed":
+			owner = event.To
+		case "RentTaken", "RentFinished":
+		default:
+			continue
+		}
+		blockTime, ok := times[event.BlockNumber]
+		if !ok {
+			header, err := ClientETH.HeaderByNumber(context.Background(), new(big.Int).SetUint64(event.BlockNumber))
+			if err != nil {
+				return nil, fmt.Errorf("get block %d: %w", event.BlockNumber, err)
+			}
+			blockTime = time.Unix(int64(header.Time), 0)
+			times[event.BlockNumber] = blockTime
+		}
+		history = append(history, &HistoryType{
+			Event: event,
+			Time: blockTime,
+			Owner: owner,
+		})
+	}
+	return history, nil
+}
+
+var (
+	parsedABI abi.ABI
+	parsedABIErr error
+	parseABIOnce sync.Once
+)
+
+// ABI of contract.sol is parsed once and shared by all callers.
+func contractABI() (abi.ABI, error) {
+	parseABIOnce.Do(func() {
+		parsedABI, parsedABIErr = abi.JSON(strings.NewReader(contract.ContractABI))
+		if parsedABIErr != nil {
+			parsedABIErr = fmt.Errorf("parse abi: %w", parsedABIErr)
+		}
+	})
+	return parsedABI, parsedABIErr
+}
+
+func ParseEvent(log types.Log) (*EventType, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("parse event: log without topics")
+	}
+	parsed, err := contractABI()
+	if err != nil {
+		return nil, err
+	}
+	desc, err := parsed.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, fmt.Errorf("parse event: %w", err)
+	}
+	event := &EventType{
+		Name: desc.Name,
+		BlockNumber: log.BlockNumber,
+		LogIndex: log.Index,
+		TxHash: log.TxHash,
+	}
+	switch desc.Name {
+	case "EstateCreated":
+		ev, err := Instance.ParseEstateCreated(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "estate", ev.EstateId, ev.EstateId
+		event.To = ev.Owner
+	case "PresentCreated":
+		ev, err := Instance.ParsePresentCreated(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "present", ev.PresentId, ev.EstateId
+		event.From, event.To = ev.From, ev.To
+	case "PresentCancelled":
+		ev, err := Instance.ParsePresentCancelled(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "present", ev.PresentId, ev.EstateId
+	case "PresentConfirmed":
+		ev, err := Instance.ParsePresentConfirmed(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "present", ev.PresentId, ev.EstateId
+		event.From, event.To = ev.From, ev.To
+	case "SaleCreated":
+		ev, err := Instance.ParseSaleCreated(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "sale", ev.SaleId, ev.EstateId
+		event.From, event.Value = ev.Owner, ev.Price
+	case "BidPlaced":
+		ev, err := Instance.ParseBidPlaced(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "sale", ev.SaleId, ev.EstateId
+		event.From, event.Value = ev.Customer, ev.Price
+	case "BidWithdrawn":
+		ev, err := Instance.ParseBidWithdrawn(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "sale", ev.SaleId, ev.EstateId
+		event.From = ev.Customer
+	case "SaleCancelled":
+		ev, err := Instance.ParseSaleCancelled(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "sale", ev.SaleId, ev.EstateId
+	case "SaleConfirmed":
+		ev, err := Instance.ParseSaleConfirmed(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "sale", ev.SaleId, ev.EstateId
+		event.From, event.To, event.Value = ev.From, ev.To, ev.Price
+	case "RentCreated":
+		ev, err := Instance.ParseRentCreated(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "rent", ev.RentId, ev.EstateId
+		event.From, event.Value = ev.Owner, ev.Money
+	case "RentTaken":
+		ev, err := Instance.ParseRentTaken(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "rent", ev.RentId, ev.EstateId
+		event.To, event.Value = ev.Renter, ev.Money
+	case "RentCancelled":
+		ev, err := Instance.ParseRentCancelled(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "rent", ev.RentId, ev.EstateId
+	case "RentFinished":
+		ev, err := Instance.ParseRentFinished(log)
+		if err != nil {
+			return nil, err
+		}
+		event.Record, event.Id, event.EstateId = "rent", ev.RentId, ev.EstateId
+		event.To = ev.Renter
+	default:
+		return nil, fmt.Errorf("parse event: unknown event %s", desc.Name)
+	}
+	return event, nil
+}
+
+func (event *EventType) String() string {
+	str := fmt.Sprintf("[%d] %s %s %s (estate %s)", event.BlockNumber, event.Name, event.Record, event.Id, event.EstateId)
+	if event.From != (common.Address{}) {
+		str += " from " + event.From.Hex()
+	}
+	if event.To != (common.Address{}) {
+		str += " to " + event.To.Hex()
+	}
+	if event.Value != nil {
+		str += " value " + event.Value.String()
+	}
+	return str
+}
+
+func (step *HistoryType) String() string {
+	return fmt.Sprintf("%s %s\n\towner %s tx %s", step.Time.Format(time.RFC3339), step.Event, step.Owner.Hex(), step.Event.TxHash.Hex())
+}
+
+func SaleHasCustomer(sale *Sale, address string) bool {
+	for _, customer := range sale.Customers {
+		if strings.ToLower(address) == strings.ToLower(customer.Hex()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter is "all", "my" (address of user) or address.
+func MatchAddress(filter string, user *UserType, addresses ...common.Address) bool {
+	switch filter {
+	case "all":
+		return true
+	case "my":
+		filter = user.AddressHex
+	}
+	for _, address := range addresses {
+		if strings.ToLower(filter) == strings.ToLower(address.Hex()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Value to send with to_rent, it must be exactly the money of rent.
+func RentValue(user *UserType, rentId *big.Int) (*big.Int, error) {
+	rent, err := Backend.GetRents(user, rentId)
+	if err != nil {
+		return nil, fmt.Errorf("get rent %s: %w", rentId, err)
+	}
+	return rent.Money, nil
+}
+
+// Deadline is zero until somebody takes the rent.
+func RentDeadline(rent *Rent) string {
+	if rent.Deadline.Sign() == 0 {
+		return "-"
+	}
+	return time.Unix(rent.Deadline.Int64(), 0).Format("2006-01-02 15:04:05")
+}
+
+type EstateStr struct {
+	Id *big.Int
+    Owner string
+    Info string
+    Squere *big.Int
+    UsefulSquere *big.Int
+    RenterAddress string
+    PresentStatus bool
+    SaleStatus bool
+    RentStatus bool
+}
+
+func EstatesToString(estate *Estate) *EstateStr {
+	return &EstateStr{
+		Id: estate.Id,
+		Owner: estate.Owner.Hex(),
+		Info: estate.Info,
+		Squere: estate.Squere,
+		UsefulSquere: estate.UsefulSquere,
+		RenterAddress: estate.RenterAddress.Hex(),
+		PresentStatus: estate.PresentStatus,
+		SaleStatus: estate.SaleStatus,
+		RentStatus: estate.RentStatus,
+	}
+}
+
+type PresentStr struct {
+	Id *big.Int
+	EstateId *big.Int
+	AddressFrom string
+	AddressTo string
+	Finished bool
+}
+
+func PresentsToString(present *Present) *PresentStr {
+	return &PresentStr{
+		Id: present.Id,
+		EstateId: present.EstateId,
+		AddressFrom: present.AddressFrom.Hex(),
+		AddressTo: present.AddressTo.Hex(),
+		Finished: present.Finished,
+	}
+}
+
+type SaleCustomerStr struct {
+	Index int
+	Address string
+	Price *big.Int
+}
+
+type SaleStr struct {
+	Id *big.Int
+	EstateId *big.Int
+	Owner string
+	Price *big.Int
+	Customers []SaleCustomerStr
+	Finished bool
+}
+
+func SalesToString(sale *Sale) *SaleStr {
+	customers := make([]SaleCustomerStr, 0, len(sale.Customers))
+	for i, customer := range sale.Customers {
+		customers = append(customers, SaleCustomerStr{
+			Index: i,
+			Address: customer.Hex(),
+			Price: sale.Prices[i],
+		})
+	}
+	return &SaleStr{
+		Id: sale.Id,
+		EstateId: sale.EstateId,
+		Owner: sale.Owner.Hex(),
+		Price: sale.Price,
+		Customers: customers,
+		Finished: sale.Finished,
+	}
+}
+
+type RentStr struct {
+	Id *big.Int
+	EstateId *big.Int
+	OwnerAddress string
+	RenterAddress string
+	Time *big.Int
+	Money *big.Int
+	Deadline string
+	Finished bool
+	CanCancel bool
+	CanFinish bool
+}
+
+// CanCancel and CanFinish mirror the require checks of cancel_rent and finish_rent.
+func RentsToString(rent *Rent) *RentStr {
+	return &RentStr{
+		Id: rent.Id,
+		EstateId: rent.EstateId,
+		OwnerAddress: rent.OwnerAddress.Hex(),
+		RenterAddress: rent.RenterAddress.Hex(),
+		Time: rent.Time,
+		Money: rent.Money,
+		Deadline: RentDeadline(rent),
+		Finished: rent.Finished,
+		CanCancel: !rent.Finished && rent.RenterAddress == (common.Address{}),
+		CanFinish: !rent.Finished && rent.Deadline.Cmp(big.NewInt(time.Now().Unix())) == -1,
+	}
+}